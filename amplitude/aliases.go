@@ -10,12 +10,27 @@ type (
 	Plan              = types.Plan
 	IngestionMetadata = types.IngestionMetadata
 	ServerZone        = types.ServerZone
+	QueueFullPolicy   = types.QueueFullPolicy
+	BackoffStrategy   = types.BackoffStrategy
+	Endpoint          = types.Endpoint
+	ValidationPolicy  = types.ValidationPolicy
+	EndpointLimits    = types.EndpointLimits
+
+	CircuitBreakerState = types.CircuitBreakerState
+
+	TrackingPlan         = types.TrackingPlan
+	TrackingPlanEvent    = types.TrackingPlanEvent
+	TrackingPlanProperty = types.TrackingPlanProperty
+	TrackingPlanMode     = types.TrackingPlanMode
+	PropertyType         = types.PropertyType
 
 	EventOptions = types.EventOptions
 	Event        = types.Event
 	IdentityOp   = types.IdentityOp
 	Identify     = types.Identify
 	Revenue      = types.Revenue
+	RevenueOrder = types.RevenueOrder
+	Properties   = types.Properties
 
 	PluginType                = types.PluginType
 	Plugin                    = types.Plugin
@@ -23,23 +38,105 @@ type (
 	EnrichmentPlugin          = types.EnrichmentPlugin
 	DestinationPlugin         = types.DestinationPlugin
 	ExtendedDestinationPlugin = types.ExtendedDestinationPlugin
+	ContextDestinationPlugin  = types.ContextDestinationPlugin
+	ResultObserverPlugin      = types.ResultObserverPlugin
 	ExecuteResult             = types.ExecuteResult
 
-	EventStorage = types.EventStorage
-	Logger       = types.Logger
+	EventStorage        = types.EventStorage
+	LeasingEventStorage = types.LeasingEventStorage
+	StorageEvent        = types.StorageEvent
+	EventLease          = types.EventLease
+	Logger              = types.Logger
+	Metrics             = types.Metrics
+	TracerProvider      = types.TracerProvider
+	Tracer              = types.Tracer
+	Span                = types.Span
+	Compressor          = types.Compressor
+	CurrencyConverter   = types.CurrencyConverter
+
+	ValidationError    = types.ValidationError
+	IdentifyValidation = types.IdentifyValidation
+	IdentifyIssue      = types.IdentifyIssue
+	IdentifyIssueCode  = types.IdentifyIssueCode
 )
 
 const (
 	ServerZoneUS = types.ServerZoneUS
 	ServerZoneEU = types.ServerZoneEU
 
+	QueueFullPolicyDropNewest     = types.QueueFullPolicyDropNewest
+	QueueFullPolicyDropOldest     = types.QueueFullPolicyDropOldest
+	QueueFullPolicyBlock          = types.QueueFullPolicyBlock
+	QueueFullPolicySpillToStorage = types.QueueFullPolicySpillToStorage
+
+	BackoffExponential        = types.BackoffExponential
+	BackoffFullJitter         = types.BackoffFullJitter
+	BackoffDecorrelatedJitter = types.BackoffDecorrelatedJitter
+
+	EndpointHTTPAPI = types.EndpointHTTPAPI
+	EndpointBatch   = types.EndpointBatch
+
+	CircuitBreakerClosed   = types.CircuitBreakerClosed
+	CircuitBreakerOpen     = types.CircuitBreakerOpen
+	CircuitBreakerHalfOpen = types.CircuitBreakerHalfOpen
+
+	ValidationPolicyReject      = types.ValidationPolicyReject
+	ValidationPolicyRepair      = types.ValidationPolicyRepair
+	ValidationPolicyPassThrough = types.ValidationPolicyPassThrough
+
+	TrackingPlanModeLog      = types.TrackingPlanModeLog
+	TrackingPlanModeAnnotate = types.TrackingPlanModeAnnotate
+	TrackingPlanModeBlock    = types.TrackingPlanModeBlock
+
+	PropertyTypeAny     = types.PropertyTypeAny
+	PropertyTypeString  = types.PropertyTypeString
+	PropertyTypeNumber  = types.PropertyTypeNumber
+	PropertyTypeInteger = types.PropertyTypeInteger
+	PropertyTypeBoolean = types.PropertyTypeBoolean
+	PropertyTypeArray   = types.PropertyTypeArray
+	PropertyTypeObject  = types.PropertyTypeObject
+
 	PluginTypeBefore      = types.PluginTypeBefore
 	PluginTypeEnrichment  = types.PluginTypeEnrichment
 	PluginTypeDestination = types.PluginTypeDestination
 
+	IdentifyIssueEmptyProperties   = types.IdentifyIssueEmptyProperties
+	IdentifyIssueEmptyProperty     = types.IdentifyIssueEmptyProperty
+	IdentifyIssueNilValue          = types.IdentifyIssueNilValue
+	IdentifyIssueInvalidValue      = types.IdentifyIssueInvalidValue
+	IdentifyIssueDuplicateProperty = types.IdentifyIssueDuplicateProperty
+	IdentifyIssueAfterClearAll     = types.IdentifyIssueAfterClearAll
+	IdentifyIssueClearedByClearAll = types.IdentifyIssueClearedByClearAll
+
+	IdentityOpAdd        = types.IdentityOpAdd
+	IdentityOpAppend     = types.IdentityOpAppend
+	IdentityOpClearAll   = types.IdentityOpClearAll
+	IdentityOpPrepend    = types.IdentityOpPrepend
+	IdentityOpSet        = types.IdentityOpSet
+	IdentityOpSetOnce    = types.IdentityOpSetOnce
+	IdentityOpUnset      = types.IdentityOpUnset
+	IdentityOpPreInsert  = types.IdentityOpPreInsert
+	IdentityOpPostInsert = types.IdentityOpPostInsert
+	IdentityOpRemove     = types.IdentityOpRemove
+
+	RevenueTypeRefund     = types.RevenueTypeRefund
+	RevenueTypeChargeback = types.RevenueTypeChargeback
+
 	IdentifyEventType      = constants.IdentifyEventType
 	GroupIdentifyEventType = constants.GroupIdentifyEventType
 	RevenueEventType       = constants.RevenueEventType
 )
 
-var NewConfig = types.NewConfig
+var (
+	ErrClientOptedOut = types.ErrClientOptedOut
+	ErrClientShutdown = types.ErrClientShutdown
+	ErrQueueFull      = types.ErrQueueFull
+	ErrEventFiltered  = types.ErrEventFiltered
+	ErrInvalidEvent   = types.ErrInvalidEvent
+)
+
+var (
+	NewConfig     = types.NewConfig
+	NewProperties = types.NewProperties
+	ValidateGroup = types.ValidateGroup
+)
@@ -1,12 +1,20 @@
 package amplitude
 
 import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
 	"github.com/amplitude/analytics-go/amplitude/constants"
 	"github.com/amplitude/analytics-go/amplitude/internal"
 	"github.com/amplitude/analytics-go/amplitude/loggers"
+	"github.com/amplitude/analytics-go/amplitude/metrics"
 	"github.com/amplitude/analytics-go/amplitude/plugins/before"
 	"github.com/amplitude/analytics-go/amplitude/plugins/destination"
 	"github.com/amplitude/analytics-go/amplitude/storages"
+	"github.com/amplitude/analytics-go/amplitude/tracing"
 )
 
 type Client interface {
@@ -25,27 +33,89 @@ type Client interface {
 	Config() Config
 }
 
+// ExtendedClient is a Client with group membership, revenue orders, delivery results and context-aware methods.
+// The client returned by NewClient implements it, so Client stays the same for its implementations and mocks.
+type ExtendedClient interface {
+	Client
+
+	GroupIdentifyMany(groupType string, groupNames []string, identify Identify, eventOptions EventOptions)
+	AddToGroup(groupType string, groupNames []string, eventOptions EventOptions)
+	RemoveFromGroup(groupType string, groupNames []string, eventOptions EventOptions)
+	RevenueOrder(order RevenueOrder, eventOptions EventOptions)
+
+	TrackContext(ctx context.Context, event Event) error
+	TrackWithResult(ctx context.Context, event Event) (*TrackResult, error)
+	TrackAndWait(ctx context.Context, event Event) (ExecuteResult, error)
+	IdentifyContext(ctx context.Context, identify Identify, eventOptions EventOptions) error
+	GroupIdentifyContext(ctx context.Context, groupType string, groupName string, identify Identify, eventOptions EventOptions) error
+	GroupIdentifyManyContext(
+		ctx context.Context, groupType string, groupNames []string, identify Identify, eventOptions EventOptions,
+	) error
+	SetGroupContext(ctx context.Context, groupType string, groupName []string, eventOptions EventOptions) error
+	AddToGroupContext(ctx context.Context, groupType string, groupNames []string, eventOptions EventOptions) error
+	RemoveFromGroupContext(ctx context.Context, groupType string, groupNames []string, eventOptions EventOptions) error
+	RevenueContext(ctx context.Context, revenue Revenue, eventOptions EventOptions) error
+	RevenueOrderContext(ctx context.Context, order RevenueOrder, eventOptions EventOptions) error
+
+	FlushContext(ctx context.Context) error
+	ShutdownContext(ctx context.Context) error
+}
+
+// NewExtendedClient is like NewClient, but returns the client as an ExtendedClient.
+func NewExtendedClient(config Config) ExtendedClient {
+	return NewClient(config).(ExtendedClient)
+}
+
 func NewClient(config Config) Client {
 	setConfigDefaultValues(&config)
 	setSafeExecuteCallback(&config)
+
+	trackResults := newTrackResults()
+
 	config.Logger.Debugf("Client initialized")
 
+	tracer := tracing.NewTracer(config.TracerProvider)
+
 	client := &client{
-		config:   config,
-		optOut:   internal.NewAtomicBool(config.OptOut),
-		timeline: &timeline{logger: config.Logger},
+		config:       config,
+		optOut:       internal.NewAtomicBool(config.OptOut),
+		shutdown:     internal.NewAtomicBool(false),
+		timeline:     &timeline{logger: config.Logger, metrics: config.Metrics, tracer: tracer},
+		trackResults: trackResults,
+		tracer:       tracer,
 	}
 
-	client.Add(destination.NewAmplitudePlugin())
+	if config.StickyGroups {
+		client.stickyGroups = internal.NewUserGroups(config.StickyGroupsMaxUsers)
+	}
+
+	amplitudePlugin := destination.NewAmplitudePlugin()
+	if observer, ok := amplitudePlugin.(resultObserver); ok {
+		observer.SetResultObserver(client.observeResult)
+	}
+
+	client.Add(amplitudePlugin)
 	client.Add(before.NewContextPlugin())
 
 	return client
 }
 
+// resultObserver is implemented by the Amplitude destination to report results of events
+// to the client without going through ExecuteCallback.
+type resultObserver interface {
+	SetResultObserver(resultObserver func(result ExecuteResult))
+}
+
+var _ ExtendedClient = (*client)(nil)
+
 type client struct {
-	config   Config
-	timeline *timeline
-	optOut   *internal.AtomicBool
+	config       Config
+	timeline     *timeline
+	optOut       *internal.AtomicBool
+	shutdown     *internal.AtomicBool
+	trackResults *trackResults
+	tracer       Tracer
+	stickyGroups *internal.UserGroups
 }
 
 func (c *client) Config() Config {
@@ -58,6 +128,92 @@ func (c *client) Track(event Event) {
 		return
 	}
 
+	c.prepareEvent(&event)
+
+	c.config.Logger.Debugf("Track event: \n\t%+v", event)
+
+	ctx, span := c.startTrackSpan(context.Background(), &event)
+	defer span.End()
+
+	c.timeline.Process(ctx, &event)
+}
+
+// TrackContext processes and sends the given event object.
+// It returns an error if the event was not accepted by every destination plugin.
+func (c *client) TrackContext(ctx context.Context, event Event) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	c.prepareEvent(&event)
+
+	c.config.Logger.Debugf("Track event: \n\t%+v", event)
+
+	return c.processContext(ctx, &event)
+}
+
+// TrackWithResult processes the given event object and returns its pending delivery result.
+// The result resolves once the Amplitude destination has sent the event or given up on it.
+// If the Amplitude destination is removed, it only resolves on Shutdown, so wait for it with a context.
+func (c *client) TrackWithResult(ctx context.Context, event Event) (*TrackResult, error) {
+	if err := c.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	c.prepareEvent(&event)
+
+	if event.InsertID == "" {
+		event.InsertID = uuid.NewString()
+	}
+
+	result := c.trackResults.add(&event)
+
+	c.config.Logger.Debugf("Track event: \n\t%+v", event)
+
+	if err := c.processContext(ctx, &event); err != nil {
+		c.trackResults.remove(event.InsertID, result)
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// TrackAndWait processes and sends the given event object, flushing the buffer,
+// and waits until the Amplitude destination reports the delivery result of the event.
+func (c *client) TrackAndWait(ctx context.Context, event Event) (ExecuteResult, error) {
+	result, err := c.TrackWithResult(ctx, event)
+	if err != nil {
+		return ExecuteResult{}, err
+	}
+
+	if err := c.FlushContext(ctx); err != nil {
+		return ExecuteResult{}, err
+	}
+
+	return result.Wait(ctx)
+}
+
+func (c *client) processContext(ctx context.Context, event *Event) error {
+	ctx, span := c.startTrackSpan(ctx, event)
+	defer span.End()
+
+	err := c.timeline.ProcessContext(ctx, event)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
+
+func (c *client) startTrackSpan(ctx context.Context, event *Event) (context.Context, Span) {
+	ctx, span := c.tracer.Start(ctx, tracing.TrackSpan)
+	span.SetAttribute(tracing.EventTypeAttribute, event.EventType)
+
+	return ctx, span
+}
+
+func (c *client) prepareEvent(event *Event) {
 	if event.Plan == nil {
 		event.Plan = c.config.Plan
 	}
@@ -74,8 +230,35 @@ func (c *client) Track(event Event) {
 		event.EventOptions.DeviceID = event.DeviceID
 	}
 
-	c.config.Logger.Debugf("Track event: \n\t%+v", event)
-	c.timeline.Process(&event)
+	c.addStickyGroups(event)
+}
+
+// addStickyGroups adds the sticky groups of the user to the event, keeping group types the event already has.
+func (c *client) addStickyGroups(event *Event) {
+	if c.stickyGroups == nil || event.EventType == constants.IdentifyEventType ||
+		event.EventType == constants.GroupIdentifyEventType {
+		return
+	}
+
+	groups := c.stickyGroups.Get(stickyGroupsKey(event.EventOptions))
+	if len(groups) == 0 {
+		return
+	}
+
+	// Groups of the event may be shared with the caller, so they are copied instead of changed.
+	for groupType, groupNames := range event.Groups {
+		groups[groupType] = groupNames
+	}
+
+	event.Groups = groups
+}
+
+func stickyGroupsKey(eventOptions EventOptions) string {
+	if eventOptions.UserID != "" {
+		return "user:" + eventOptions.UserID
+	}
+
+	return "device:" + eventOptions.DeviceID
 }
 
 // Identify sends an identify event to update user Properties.
@@ -84,6 +267,26 @@ func (c *client) Identify(identify Identify, eventOptions EventOptions) {
 		return
 	}
 
+	if identifyEvent, err := c.identifyEvent(identify, eventOptions); err == nil {
+		c.Track(identifyEvent)
+	}
+}
+
+// IdentifyContext sends an identify event to update user Properties.
+func (c *client) IdentifyContext(ctx context.Context, identify Identify, eventOptions EventOptions) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	identifyEvent, err := c.identifyEvent(identify, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, identifyEvent)
+}
+
+func (c *client) identifyEvent(identify Identify, eventOptions EventOptions) (Event, error) {
 	validateErrors, validateWarnings := identify.Validate()
 
 	for _, validateWarning := range validateWarnings {
@@ -94,15 +297,15 @@ func (c *client) Identify(identify Identify, eventOptions EventOptions) {
 		for _, validateError := range validateErrors {
 			c.config.Logger.Errorf("Identify: %s", validateError)
 		}
-	} else {
-		identifyEvent := Event{
-			EventType:      constants.IdentifyEventType,
-			EventOptions:   eventOptions,
-			UserProperties: identify.Properties,
-		}
 
-		c.Track(identifyEvent)
+		return Event{}, &ValidationError{Errors: validateErrors}
 	}
+
+	return Event{
+		EventType:      constants.IdentifyEventType,
+		EventOptions:   eventOptions,
+		UserProperties: identify.Properties,
+	}, nil
 }
 
 // GroupIdentify sends a group identify event to update group Properties.
@@ -111,6 +314,93 @@ func (c *client) GroupIdentify(groupType string, groupName string, identify Iden
 		return
 	}
 
+	if groupIdentifyEvent, err := c.groupIdentifyEvent(groupType, groupName, identify, eventOptions); err == nil {
+		c.Track(groupIdentifyEvent)
+	}
+}
+
+// GroupIdentifyContext sends a group identify event to update group Properties.
+func (c *client) GroupIdentifyContext(
+	ctx context.Context, groupType string, groupName string, identify Identify, eventOptions EventOptions,
+) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	groupIdentifyEvent, err := c.groupIdentifyEvent(groupType, groupName, identify, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, groupIdentifyEvent)
+}
+
+// GroupIdentifyMany sends a group identify event for every group name to update their group Properties.
+func (c *client) GroupIdentifyMany(groupType string, groupNames []string, identify Identify, eventOptions EventOptions) {
+	if !c.enabled() {
+		return
+	}
+
+	groupIdentifyEvents, err := c.groupIdentifyEvents(groupType, groupNames, identify, eventOptions)
+	if err != nil {
+		return
+	}
+
+	for _, groupIdentifyEvent := range groupIdentifyEvents {
+		c.Track(groupIdentifyEvent)
+	}
+}
+
+// GroupIdentifyManyContext sends a group identify event for every group name to update their group Properties.
+// Nothing is sent if a group name is invalid. It stops at the first event that fails,
+// events of the group names before it are already sent.
+func (c *client) GroupIdentifyManyContext(
+	ctx context.Context, groupType string, groupNames []string, identify Identify, eventOptions EventOptions,
+) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	groupIdentifyEvents, err := c.groupIdentifyEvents(groupType, groupNames, identify, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, groupIdentifyEvent := range groupIdentifyEvents {
+		if err := c.TrackContext(ctx, groupIdentifyEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *client) groupIdentifyEvents(
+	groupType string, groupNames []string, identify Identify, eventOptions EventOptions,
+) ([]Event, error) {
+	if err := c.validateGroup(groupType, groupNames); err != nil {
+		return nil, err
+	}
+
+	groupIdentifyEvents := make([]Event, 0, len(groupNames))
+
+	for _, groupName := range groupNames {
+		groupIdentifyEvent, err := c.groupIdentifyEvent(groupType, groupName, identify, eventOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		groupIdentifyEvents = append(groupIdentifyEvents, groupIdentifyEvent)
+	}
+
+	return groupIdentifyEvents, nil
+}
+
+func (c *client) groupIdentifyEvent(groupType string, groupName string, identify Identify, eventOptions EventOptions) (Event, error) {
+	if err := c.validateGroup(groupType, []string{groupName}); err != nil {
+		return Event{}, err
+	}
+
 	validateErrors, validateWarnings := identify.Validate()
 
 	for _, validateWarning := range validateWarnings {
@@ -121,16 +411,16 @@ func (c *client) GroupIdentify(groupType string, groupName string, identify Iden
 		for _, validateError := range validateErrors {
 			c.config.Logger.Errorf("Invalid Identify: %s", validateError)
 		}
-	} else {
-		groupIdentifyEvent := Event{
-			EventType:       constants.GroupIdentifyEventType,
-			EventOptions:    eventOptions,
-			Groups:          map[string][]string{groupType: {groupName}},
-			GroupProperties: identify.Properties,
-		}
 
-		c.Track(groupIdentifyEvent)
+		return Event{}, &ValidationError{Errors: validateErrors}
 	}
+
+	return Event{
+		EventType:       constants.GroupIdentifyEventType,
+		EventOptions:    eventOptions,
+		Groups:          map[string][]string{groupType: {groupName}},
+		GroupProperties: identify.Properties,
+	}, nil
 }
 
 // Revenue sends a revenue event with revenue info in eventProperties.
@@ -139,27 +429,172 @@ func (c *client) Revenue(revenue Revenue, eventOptions EventOptions) {
 		return
 	}
 
+	if revenueEvent, err := c.revenueEvent(revenue, eventOptions); err == nil {
+		c.Track(revenueEvent)
+	}
+}
+
+// RevenueContext sends a revenue event with revenue info in eventProperties.
+func (c *client) RevenueContext(ctx context.Context, revenue Revenue, eventOptions EventOptions) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	revenueEvent, err := c.revenueEvent(revenue, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, revenueEvent)
+}
+
+// RevenueOrder sends a revenue event for every item of the order.
+func (c *client) RevenueOrder(order RevenueOrder, eventOptions EventOptions) {
+	if !c.enabled() {
+		return
+	}
+
+	revenueEvents, err := c.revenueOrderEvents(order, eventOptions)
+	if err != nil {
+		return
+	}
+
+	for _, revenueEvent := range revenueEvents {
+		c.Track(revenueEvent)
+	}
+}
+
+// RevenueOrderContext sends a revenue event for every item of the order.
+// Nothing is sent if an item is invalid. It stops at the first event that fails,
+// events of the items before it are already sent.
+func (c *client) RevenueOrderContext(ctx context.Context, order RevenueOrder, eventOptions EventOptions) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	revenueEvents, err := c.revenueOrderEvents(order, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, revenueEvent := range revenueEvents {
+		if err := c.TrackContext(ctx, revenueEvent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revenueOrderEvents returns the revenue events of the items of the order.
+// An InsertID of eventOptions is suffixed with the index of the item, so every event has its own.
+func (c *client) revenueOrderEvents(order RevenueOrder, eventOptions EventOptions) ([]Event, error) {
+	if validateErrors := order.Validate(); len(validateErrors) > 0 {
+		for _, validateError := range validateErrors {
+			c.config.Logger.Errorf("Invalid RevenueOrder: %s", validateError)
+		}
+
+		return nil, &ValidationError{Errors: validateErrors}
+	}
+
+	revenues := order.Revenues()
+	revenueEvents := make([]Event, 0, len(revenues))
+
+	for i, revenue := range revenues {
+		itemEventOptions := eventOptions
+		if eventOptions.InsertID != "" {
+			itemEventOptions.InsertID = eventOptions.InsertID + "-" + strconv.Itoa(i)
+		}
+
+		revenueEvent, err := c.revenueEvent(revenue, itemEventOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		revenueEvents = append(revenueEvents, revenueEvent)
+	}
+
+	return revenueEvents, nil
+}
+
+func (c *client) revenueEvent(revenue Revenue, eventOptions EventOptions) (Event, error) {
 	if validateErrors := revenue.Validate(); len(validateErrors) > 0 {
 		for _, validateError := range validateErrors {
 			c.config.Logger.Errorf("Invalid Revenue: %s", validateError)
 		}
-	} else {
-		revenueEvent := Event{
-			EventType:    constants.RevenueEventType,
-			EventOptions: eventOptions,
-			EventProperties: map[string]interface{}{
-				constants.RevenueProductID:  revenue.ProductID,
-				constants.RevenueQuantity:   revenue.Quantity,
-				constants.RevenuePrice:      revenue.Price,
-				constants.RevenueType:       revenue.RevenueType,
-				constants.RevenueReceipt:    revenue.Receipt,
-				constants.RevenueReceiptSig: revenue.ReceiptSig,
-				constants.Currency:          revenue.Currency,
-				constants.DefaultRevenue:    revenue.Revenue,
-			},
-		}
-		c.Track(revenueEvent)
+
+		return Event{}, &ValidationError{Errors: validateErrors}
+	}
+
+	eventProperties := make(map[string]interface{}, len(revenue.Properties)+12)
+
+	// Properties of the revenue may be shared with the caller, so they are copied instead of changed.
+	for property, value := range revenue.Properties {
+		eventProperties[property] = value
+	}
+
+	revenue = c.convertRevenue(revenue, eventProperties)
+	price, amount := revenue.SignedAmounts()
+
+	eventProperties[constants.RevenueProductID] = revenue.ProductID
+	eventProperties[constants.RevenueQuantity] = revenue.Quantity
+	eventProperties[constants.RevenuePrice] = price
+	eventProperties[constants.RevenueType] = revenue.RevenueType
+	eventProperties[constants.RevenueReceipt] = revenue.Receipt
+	eventProperties[constants.RevenueReceiptSig] = revenue.ReceiptSig
+	eventProperties[constants.Currency] = revenue.Currency
+	eventProperties[constants.DefaultRevenue] = amount
+
+	if revenue.OrderID != "" {
+		eventProperties[constants.RevenueOrderID] = revenue.OrderID
+	}
+
+	return Event{
+		EventType:       constants.RevenueEventType,
+		EventOptions:    eventOptions,
+		EventProperties: eventProperties,
+	}, nil
+}
+
+// convertRevenue converts the amounts of the revenue to Config.ReportingCurrency,
+// adding the original currency and amounts to eventProperties.
+func (c *client) convertRevenue(revenue Revenue, eventProperties map[string]interface{}) Revenue {
+	converter := c.config.CurrencyConverter
+	if converter == nil || c.config.ReportingCurrency == "" || revenue.Currency == "" {
+		return revenue
+	}
+
+	// Currency codes are compared and converted in upper case, e.g. "usd" is the same as "USD".
+	currency, reportingCurrency := strings.ToUpper(revenue.Currency), strings.ToUpper(c.config.ReportingCurrency)
+	if currency == reportingCurrency {
+		revenue.Currency = reportingCurrency
+
+		return revenue
+	}
+
+	price, err := converter.Convert(revenue.Price, currency, reportingCurrency)
+	if err != nil {
+		c.config.Logger.Warnf("Revenue in %s is sent without conversion: %s", revenue.Currency, err)
+
+		return revenue
+	}
+
+	amount, err := converter.Convert(revenue.Revenue, currency, reportingCurrency)
+	if err != nil {
+		c.config.Logger.Warnf("Revenue in %s is sent without conversion: %s", revenue.Currency, err)
+
+		return revenue
 	}
+
+	eventProperties[constants.RevenueOriginalCurrency] = revenue.Currency
+	eventProperties[constants.RevenueOriginalPrice] = revenue.Price
+	eventProperties[constants.RevenueOriginalRevenue] = revenue.Revenue
+
+	revenue.Currency = reportingCurrency
+	revenue.Price = price
+	revenue.Revenue = amount
+
+	return revenue
 }
 
 // SetGroup sends an identify event to put a user in group(s)
@@ -169,9 +604,134 @@ func (c *client) SetGroup(groupType string, groupName []string, eventOptions Eve
 		return
 	}
 
+	if groupEvent, err := c.groupMembershipEvent(IdentityOpSet, groupType, groupName, eventOptions); err == nil {
+		c.Track(groupEvent)
+	}
+}
+
+// SetGroupContext sends an identify event to put a user in group(s)
+// by setting group type and group name as user property for a user.
+func (c *client) SetGroupContext(ctx context.Context, groupType string, groupName []string, eventOptions EventOptions) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	groupEvent, err := c.groupMembershipEvent(IdentityOpSet, groupType, groupName, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, groupEvent)
+}
+
+// AddToGroup sends an identify event to add a user to group(s)
+// by appending group names to the group type user property, keeping the other groups of the user.
+func (c *client) AddToGroup(groupType string, groupNames []string, eventOptions EventOptions) {
+	if !c.enabled() {
+		return
+	}
+
+	if groupEvent, err := c.groupMembershipEvent(IdentityOpAppend, groupType, groupNames, eventOptions); err == nil {
+		c.Track(groupEvent)
+	}
+}
+
+// AddToGroupContext sends an identify event to add a user to group(s)
+// by appending group names to the group type user property, keeping the other groups of the user.
+func (c *client) AddToGroupContext(ctx context.Context, groupType string, groupNames []string, eventOptions EventOptions) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	groupEvent, err := c.groupMembershipEvent(IdentityOpAppend, groupType, groupNames, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, groupEvent)
+}
+
+// RemoveFromGroup sends an identify event to remove a user from group(s)
+// by removing group names from the group type user property.
+func (c *client) RemoveFromGroup(groupType string, groupNames []string, eventOptions EventOptions) {
+	if !c.enabled() {
+		return
+	}
+
+	if groupEvent, err := c.groupMembershipEvent(IdentityOpRemove, groupType, groupNames, eventOptions); err == nil {
+		c.Track(groupEvent)
+	}
+}
+
+// RemoveFromGroupContext sends an identify event to remove a user from group(s)
+// by removing group names from the group type user property.
+func (c *client) RemoveFromGroupContext(
+	ctx context.Context, groupType string, groupNames []string, eventOptions EventOptions,
+) error {
+	if err := c.checkEnabled(); err != nil {
+		return err
+	}
+
+	groupEvent, err := c.groupMembershipEvent(IdentityOpRemove, groupType, groupNames, eventOptions)
+	if err != nil {
+		return err
+	}
+
+	return c.TrackContext(ctx, groupEvent)
+}
+
+// groupMembershipEvent returns an identify event changing the group names of a user with the $set, $append
+// or $remove operation on the group type user property, and updates the sticky groups of the user.
+func (c *client) groupMembershipEvent(
+	operation IdentityOp, groupType string, groupNames []string, eventOptions EventOptions,
+) (Event, error) {
+	if err := c.validateGroup(groupType, groupNames); err != nil {
+		return Event{}, err
+	}
+
 	identify := Identify{}
-	identify.Set(groupType, groupName)
-	c.Identify(identify, eventOptions)
+
+	switch operation {
+	case IdentityOpAppend:
+		identify.Append(groupType, groupNames)
+	case IdentityOpRemove:
+		identify.Remove(groupType, groupNames)
+	default:
+		identify.Set(groupType, groupNames)
+	}
+
+	groupEvent, err := c.identifyEvent(identify, eventOptions)
+	if err != nil {
+		return Event{}, err
+	}
+
+	if c.stickyGroups != nil {
+		key := stickyGroupsKey(eventOptions)
+
+		switch operation {
+		case IdentityOpAppend:
+			c.stickyGroups.Add(key, groupType, groupNames)
+		case IdentityOpRemove:
+			c.stickyGroups.Remove(key, groupType, groupNames)
+		default:
+			c.stickyGroups.Set(key, groupType, groupNames)
+		}
+	}
+
+	return groupEvent, nil
+}
+
+func (c *client) validateGroup(groupType string, groupNames []string) error {
+	validateErrors := ValidateGroup(groupType, groupNames)
+	if len(validateErrors) == 0 {
+		return nil
+	}
+
+	for _, validateError := range validateErrors {
+		c.config.Logger.Errorf("Invalid group: %s", validateError)
+	}
+
+	return &ValidationError{Errors: validateErrors}
 }
 
 // Flush flushes all events waiting to be sent in the buffer.
@@ -179,6 +739,13 @@ func (c *client) Flush() {
 	c.timeline.Flush()
 }
 
+// FlushContext flushes all events waiting to be sent in the buffer.
+// It returns the context error if the context is done before the flush completes,
+// in which case the flush keeps running in the background.
+func (c *client) FlushContext(ctx context.Context) error {
+	return waitContext(ctx, c.timeline.Flush)
+}
+
 // Add adds the plugin object to client instance.
 // Events tracked by this client instance will be processed by instances' plugins.
 func (c *client) Add(plugin Plugin) {
@@ -193,16 +760,68 @@ func (c *client) Remove(pluginName string) {
 	c.timeline.RemovePlugin(pluginName)
 }
 
+// observeResult resolves TrackResults and notifies ResultObserverPlugins of a result of the Amplitude destination.
+func (c *client) observeResult(result ExecuteResult) {
+	c.trackResults.resolve(result)
+	c.timeline.ObserveResult(result)
+}
+
 // Shutdown shuts the client instance down from accepting new events.
 func (c *client) Shutdown() {
-	c.optOut.Set()
+	c.shutdown.Set()
 
 	c.config.Logger.Debugf("Client shutdown")
 	c.timeline.Shutdown()
+	c.trackResults.resolveAll()
+}
+
+// ShutdownContext shuts the client instance down from accepting new events.
+// It returns ErrClientShutdown if the client is already shut down, and the context error
+// if the context is done before buffered events are flushed.
+func (c *client) ShutdownContext(ctx context.Context) error {
+	if !c.shutdown.SetIfUnset() {
+		return ErrClientShutdown
+	}
+
+	c.config.Logger.Debugf("Client shutdown")
+
+	return waitContext(ctx, func() {
+		c.timeline.Shutdown()
+		c.trackResults.resolveAll()
+	})
 }
 
 func (c *client) enabled() bool {
-	return !c.optOut.IsSet()
+	return c.checkEnabled() == nil
+}
+
+func (c *client) checkEnabled() error {
+	if c.shutdown.IsSet() {
+		return ErrClientShutdown
+	}
+
+	if c.optOut.IsSet() {
+		return ErrClientOptedOut
+	}
+
+	return nil
+}
+
+// waitContext runs fn in a goroutine and waits until it returns or the context is done.
+func waitContext(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func setConfigDefaultValues(config *Config) {
@@ -218,6 +837,10 @@ func setConfigDefaultValues(config *Config) {
 		config.FlushSizeDivider = constants.DefaultConfig.FlushSizeDivider
 	}
 
+	if config.FlushSizeRecoverAfter == 0 {
+		config.FlushSizeRecoverAfter = constants.DefaultConfig.FlushSizeRecoverAfter
+	}
+
 	if config.FlushMaxRetries == 0 {
 		config.FlushMaxRetries = constants.DefaultConfig.FlushMaxRetries
 	}
@@ -238,10 +861,50 @@ func setConfigDefaultValues(config *Config) {
 		config.RetryThrottledInterval = constants.DefaultConfig.RetryThrottledInterval
 	}
 
+	if config.StorageLeaseTimeout == 0 {
+		config.StorageLeaseTimeout = constants.DefaultConfig.StorageLeaseTimeout
+	}
+
+	if config.QueueFullBlockTimeout == 0 {
+		config.QueueFullBlockTimeout = constants.DefaultConfig.QueueFullBlockTimeout
+	}
+
+	if config.CircuitBreakerOpenTimeout == 0 {
+		config.CircuitBreakerOpenTimeout = constants.DefaultConfig.CircuitBreakerOpenTimeout
+	}
+
+	if config.MaxArrayLength == 0 {
+		config.MaxArrayLength = constants.DefaultConfig.MaxArrayLength
+	}
+
+	if config.MaxPropertyDepth == 0 {
+		config.MaxPropertyDepth = constants.DefaultConfig.MaxPropertyDepth
+	}
+
+	if config.AutoBatchBacklog == 0 {
+		config.AutoBatchBacklog = constants.DefaultConfig.AutoBatchBacklog
+	}
+
+	if config.AutoBatchDuration == 0 {
+		config.AutoBatchDuration = constants.DefaultConfig.AutoBatchDuration
+	}
+
+	if config.StickyGroupsMaxUsers <= 0 {
+		config.StickyGroupsMaxUsers = constants.DefaultConfig.StickyGroupsMaxUsers
+	}
+
 	if config.Logger == nil {
 		config.Logger = loggers.NewDefaultLogger()
 	}
 
+	if config.Metrics == nil {
+		config.Metrics = metrics.NewNoopMetrics()
+	}
+
+	if config.TracerProvider == nil {
+		config.TracerProvider = tracing.NewNoopTracerProvider()
+	}
+
 	if config.StorageFactory == nil {
 		config.StorageFactory = storages.NewInMemoryEventStorage
 	}
@@ -250,6 +913,11 @@ func setConfigDefaultValues(config *Config) {
 		config.ServerZone = constants.DefaultConfig.ServerZone
 	}
 
+	// A custom ServerURL, e.g. a proxy, only receives batch requests at an explicit BatchServerURL.
+	if config.BatchServerURL == "" && (config.ServerURL == "" || config.ServerURL == constants.ServerURLs[config.ServerZone]) {
+		config.BatchServerURL = constants.ServerBatchURLs[config.ServerZone]
+	}
+
 	if config.ServerURL == "" {
 		if config.UseBatch {
 			config.ServerURL = constants.ServerBatchURLs[config.ServerZone]
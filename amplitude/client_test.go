@@ -1,15 +1,23 @@
 package amplitude_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/amplitude/analytics-go/amplitude"
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/currency"
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
@@ -143,6 +151,144 @@ func (t *ClientSuite) TestSetGroup() {
 ]`, string(events))
 }
 
+func (t *ClientSuite) TestGroupIdentifyMany() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	identify := amplitude.Identify{}
+	identify.Set("plan", "enterprise")
+
+	err := client.GroupIdentifyManyContext(context.Background(), "workspace", []string{"acme", "globex"}, identify,
+		amplitude.EventOptions{UserID: "user-1"})
+
+	require := t.Require()
+	require.NoError(err)
+
+	events, _ := json.Marshal(destPlugin.events)
+	require.JSONEq(`[
+  {
+    "event_type": "$groupidentify",
+    "user_id": "user-1",
+    "group_properties": {"$set": {"plan": "enterprise"}},
+    "groups": {"workspace": ["acme"]}
+  },
+  {
+    "event_type": "$groupidentify",
+    "user_id": "user-1",
+    "group_properties": {"$set": {"plan": "enterprise"}},
+    "groups": {"workspace": ["globex"]}
+  }
+]`, string(events))
+}
+
+func (t *ClientSuite) TestGroupMembership() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	eventOptions := amplitude.EventOptions{UserID: "user-1"}
+	client.AddToGroup("workspace", []string{"acme", "globex"}, eventOptions)
+	client.RemoveFromGroup("workspace", []string{"acme"}, eventOptions)
+
+	events, _ := json.Marshal(destPlugin.events)
+	t.Require().JSONEq(`[
+  {
+    "event_type": "$identify",
+    "user_id": "user-1",
+    "user_properties": {"$append": {"workspace": ["acme", "globex"]}}
+  },
+  {
+    "event_type": "$identify",
+    "user_id": "user-1",
+    "user_properties": {"$remove": {"workspace": ["acme"]}}
+  }
+]`, string(events))
+}
+
+func (t *ClientSuite) TestGroupContext_Invalid() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	identify := amplitude.Identify{}
+	identify.Set("plan", "enterprise")
+
+	eventOptions := amplitude.EventOptions{UserID: "user-1"}
+	ctx := context.Background()
+
+	require := t.Require()
+
+	err := client.AddToGroupContext(ctx, "", []string{"acme"}, eventOptions)
+	require.ErrorIs(err, amplitude.ErrInvalidEvent)
+
+	var validationError *amplitude.ValidationError
+	require.True(errors.As(err, &validationError))
+	require.Equal([]string{"Group type is empty"}, validationError.Errors)
+
+	require.ErrorIs(client.RemoveFromGroupContext(ctx, "workspace", nil, eventOptions), amplitude.ErrInvalidEvent)
+	require.ErrorIs(client.SetGroupContext(ctx, "$os", []string{"acme"}, eventOptions), amplitude.ErrInvalidEvent)
+	require.ErrorIs(client.GroupIdentifyContext(ctx, "workspace", "", identify, eventOptions), amplitude.ErrInvalidEvent)
+	require.ErrorIs(client.GroupIdentifyManyContext(ctx, "workspace", []string{"acme", ""}, identify, eventOptions),
+		amplitude.ErrInvalidEvent)
+	require.Empty(destPlugin.events)
+}
+
+func (t *ClientSuite) TestStickyGroups() {
+	config := amplitude.NewConfig("your_api_key")
+	config.StickyGroups = true
+
+	client := t.createClient(config)
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	client.SetGroup("workspace", []string{"acme"}, amplitude.EventOptions{UserID: "user-1"})
+	client.AddToGroup("workspace", []string{"globex"}, amplitude.EventOptions{UserID: "user-1"})
+	client.SetGroup("org", []string{"initech"}, amplitude.EventOptions{UserID: "user-1"})
+	client.RemoveFromGroup("org", []string{"initech"}, amplitude.EventOptions{UserID: "user-1"})
+
+	groups := map[string][]string{"team": {"growth"}}
+	client.Track(amplitude.Event{EventType: "event-1", UserID: "user-1", Groups: groups})
+	client.Track(amplitude.Event{
+		EventType:    "event-2",
+		EventOptions: amplitude.EventOptions{UserID: "user-1"},
+		Groups:       map[string][]string{"workspace": {"hooli"}},
+	})
+	client.Track(amplitude.Event{EventType: "event-3", EventOptions: amplitude.EventOptions{UserID: "user-2"}})
+
+	require := t.Require()
+	require.Len(destPlugin.events, 7)
+	require.Nil(destPlugin.events[1].Groups, "identify events are left as they are")
+	require.Equal(map[string][]string{"workspace": {"acme", "globex"}, "team": {"growth"}}, destPlugin.events[4].Groups)
+	require.Equal(map[string][]string{"team": {"growth"}}, groups, "groups of the caller are not changed")
+	require.Equal(map[string][]string{"workspace": {"hooli"}}, destPlugin.events[5].Groups)
+	require.Nil(destPlugin.events[6].Groups)
+}
+
+func (t *ClientSuite) TestStickyGroups_NegativeMaxUsers() {
+	config := amplitude.NewConfig("your_api_key")
+	config.StickyGroups = true
+	config.StickyGroupsMaxUsers = -1
+
+	client := t.createClient(config)
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	require := t.Require()
+	require.Equal(10000, client.Config().StickyGroupsMaxUsers)
+
+	client.SetGroup("workspace", []string{"acme"}, amplitude.EventOptions{UserID: "user-1"})
+	client.Track(amplitude.Event{EventType: "event-1", UserID: "user-1"})
+
+	require.Len(destPlugin.events, 2)
+	require.Equal(map[string][]string{"workspace": {"acme"}}, destPlugin.events[1].Groups)
+}
+
 func (t *ClientSuite) TestRevenue() {
 	config := amplitude.NewConfig("your_api_key")
 	config.FlushQueueSize = 3
@@ -186,6 +332,157 @@ func (t *ClientSuite) TestRevenue() {
 ]`, string(events))
 }
 
+func (t *ClientSuite) TestRevenue_RefundWithProperties() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	properties := map[string]interface{}{"reason": "damaged", constants.RevenuePrice: "ignored"}
+	client.Revenue(amplitude.Revenue{
+		Price:       12.5,
+		Quantity:    1,
+		ProductID:   "product-1",
+		RevenueType: amplitude.RevenueTypeRefund,
+		Revenue:     12.5,
+		Properties:  properties,
+	}, amplitude.EventOptions{UserID: "user-1"})
+
+	require := t.Require()
+	require.Len(destPlugin.events, 1)
+	require.Equal(map[string]interface{}{
+		"reason":                    "damaged",
+		constants.RevenueProductID:  "product-1",
+		constants.RevenueQuantity:   1,
+		constants.RevenuePrice:      -12.5,
+		constants.RevenueType:       "refund",
+		constants.RevenueReceipt:    "",
+		constants.RevenueReceiptSig: "",
+		constants.Currency:          "",
+		constants.DefaultRevenue:    -12.5,
+	}, destPlugin.events[0].EventProperties)
+	require.Len(properties, 2, "properties of the caller are not changed")
+}
+
+func (t *ClientSuite) TestRevenueOrder() {
+	config := amplitude.NewConfig("your_api_key")
+	config.CurrencyConverter = currency.NewStaticConverter(map[string]float64{"USD": 1, "EUR": 1.5})
+	config.ReportingCurrency = "USD"
+
+	client := t.createClient(config)
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	err := client.RevenueOrderContext(context.Background(), amplitude.RevenueOrder{
+		OrderID:    "order-1",
+		Currency:   "EUR",
+		Properties: map[string]interface{}{"channel": "web"},
+		Items: []amplitude.Revenue{
+			{ProductID: "product-1", Price: 10, Quantity: 2, Revenue: 20},
+			{ProductID: "product-2", Price: 3, Quantity: 1, Revenue: 3, Currency: "USD"},
+			{ProductID: "product-3", Price: 1, Quantity: 1, Revenue: 1, Currency: "GBP"},
+		},
+	}, amplitude.EventOptions{UserID: "user-1", InsertID: "insert-1"})
+
+	require := t.Require()
+	require.NoError(err)
+
+	events, _ := json.Marshal(destPlugin.events)
+	require.JSONEq(`[
+  {
+    "event_type": "revenue_amount",
+    "user_id": "user-1",
+    "insert_id": "insert-1-0",
+    "event_properties": {
+      "channel": "web",
+      "order_id": "order-1",
+      "$productId": "product-1",
+      "$quantity": 2,
+      "$price": 15,
+      "$revenue": 30,
+      "$currency": "USD",
+      "$revenueType": "",
+      "$receipt": "",
+      "$receiptSig": "",
+      "original_currency": "EUR",
+      "original_price": 10,
+      "original_revenue": 20
+    }
+  },
+  {
+    "event_type": "revenue_amount",
+    "user_id": "user-1",
+    "insert_id": "insert-1-1",
+    "event_properties": {
+      "channel": "web",
+      "order_id": "order-1",
+      "$productId": "product-2",
+      "$quantity": 1,
+      "$price": 3,
+      "$revenue": 3,
+      "$currency": "USD",
+      "$revenueType": "",
+      "$receipt": "",
+      "$receiptSig": ""
+    }
+  },
+  {
+    "event_type": "revenue_amount",
+    "user_id": "user-1",
+    "insert_id": "insert-1-2",
+    "event_properties": {
+      "channel": "web",
+      "order_id": "order-1",
+      "$productId": "product-3",
+      "$quantity": 1,
+      "$price": 1,
+      "$revenue": 1,
+      "$currency": "GBP",
+      "$revenueType": "",
+      "$receipt": "",
+      "$receiptSig": ""
+    }
+  }
+]`, string(events))
+
+	err = client.RevenueOrderContext(context.Background(), amplitude.RevenueOrder{
+		OrderID: "order-2",
+		Items:   []amplitude.Revenue{{Price: 1}, {ProductID: "product-2"}},
+	}, amplitude.EventOptions{UserID: "user-1"})
+	require.ErrorIs(err, amplitude.ErrInvalidEvent)
+	require.Len(destPlugin.events, 3)
+}
+
+func (t *ClientSuite) TestRevenue_CurrencyCase() {
+	converter := &testCurrencyConverter{}
+	converter.On("Convert", 10.0, "EUR", "USD").Return(12.0, nil).Once()
+	converter.On("Convert", 0.0, "EUR", "USD").Return(0.0, nil).Once()
+
+	config := amplitude.NewConfig("your_api_key")
+	config.CurrencyConverter = converter
+	config.ReportingCurrency = "usd"
+
+	client := t.createClient(config)
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	client.Revenue(amplitude.Revenue{Price: 5, Currency: "Usd"}, amplitude.EventOptions{UserID: "user-1"})
+	client.Revenue(amplitude.Revenue{Price: 10, Currency: "eur"}, amplitude.EventOptions{UserID: "user-1"})
+
+	require := t.Require()
+	require.Len(destPlugin.events, 2)
+	require.Equal("USD", destPlugin.events[0].EventProperties[constants.Currency])
+	require.Equal(5.0, destPlugin.events[0].EventProperties[constants.RevenuePrice])
+	require.NotContains(destPlugin.events[0].EventProperties, constants.RevenueOriginalCurrency)
+
+	require.Equal("USD", destPlugin.events[1].EventProperties[constants.Currency])
+	require.Equal(12.0, destPlugin.events[1].EventProperties[constants.RevenuePrice])
+	require.Equal("eur", destPlugin.events[1].EventProperties[constants.RevenueOriginalCurrency])
+	converter.AssertExpectations(t.T())
+}
+
 func (t *ClientSuite) TestFlush() {
 	logger := &mockLogger{}
 	logger.On("Debugf", mock.Anything, mock.Anything).Return()
@@ -252,8 +549,311 @@ func (t *ClientSuite) TestPanicInPlugins() {
 	logger.AssertExpectations(t.T())
 }
 
-func (t *ClientSuite) createClient(config types.Config) amplitude.Client {
-	client := amplitude.NewClient(config)
+func (t *ClientSuite) TestTrackContext() {
+	config := amplitude.NewConfig("your_api_key")
+
+	client := t.createClient(config)
+	client.Add(&testBeforePlugin{})
+	client.Add(&testEnrichmentPlugin{})
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	require := t.Require()
+	require.NoError(client.TrackContext(context.Background(), t.createEvent(1)))
+	require.Len(destPlugin.events, 1)
+	require.Equal("IP 1 city", destPlugin.events[0].City)
+}
+
+func (t *ClientSuite) TestTrackContext_Errors() {
+	require := t.Require()
+
+	config := amplitude.NewConfig("your_api_key")
+	config.OptOut = true
+	client := t.createClient(config)
+	require.ErrorIs(client.TrackContext(context.Background(), t.createEvent(1)), amplitude.ErrClientOptedOut)
+
+	client = t.createClient(amplitude.NewConfig("your_api_key"))
+	client.Add(&testBeforePlugin{filter: true})
+	require.ErrorIs(client.TrackContext(context.Background(), t.createEvent(1)), amplitude.ErrEventFiltered)
+
+	client = t.createClient(amplitude.NewConfig("your_api_key"))
+	client.Add(&testContextDestinationPlugin{err: amplitude.ErrQueueFull})
+	err := client.TrackContext(context.Background(), t.createEvent(1))
+	require.ErrorIs(err, amplitude.ErrQueueFull)
+	require.Contains(err.Error(), "test-context-destination-plugin")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(client.TrackContext(ctx, t.createEvent(1)), context.Canceled)
+
+	client.Shutdown()
+	require.ErrorIs(client.TrackContext(context.Background(), t.createEvent(1)), amplitude.ErrClientShutdown)
+}
+
+func (t *ClientSuite) TestIdentifyContext_Invalid() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	client.Add(destPlugin)
+
+	err := client.IdentifyContext(context.Background(), amplitude.Identify{}, amplitude.EventOptions{UserID: "user-1"})
+
+	require := t.Require()
+	require.ErrorIs(err, amplitude.ErrInvalidEvent)
+
+	var validationError *amplitude.ValidationError
+	require.True(errors.As(err, &validationError))
+	require.Equal([]string{"Empty Properties"}, validationError.Errors)
+	require.Empty(destPlugin.events)
+
+	err = client.RevenueContext(context.Background(), amplitude.Revenue{}, amplitude.EventOptions{UserID: "user-1"})
+	require.ErrorIs(err, amplitude.ErrInvalidEvent)
+}
+
+func (t *ClientSuite) TestFlushContext_Deadline() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testContextDestinationPlugin{flushed: make(chan struct{})}
+	client.Add(destPlugin)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	require := t.Require()
+	require.ErrorIs(client.FlushContext(ctx), context.DeadlineExceeded)
+
+	close(destPlugin.flushed)
+	require.NoError(client.FlushContext(context.Background()))
+}
+
+func (t *ClientSuite) TestShutdownContext() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	destPlugin.On("Shutdown").Once()
+	client.Add(destPlugin)
+
+	require := t.Require()
+	require.NoError(client.ShutdownContext(context.Background()))
+	require.ErrorIs(client.ShutdownContext(context.Background()), amplitude.ErrClientShutdown)
+
+	destPlugin.AssertExpectations(t.T())
+}
+
+func (t *ClientSuite) TestShutdownContext_Concurrent() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destPlugin := &testDestinationPlugin{}
+	destPlugin.On("Shutdown").Once()
+	client.Add(destPlugin)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs <- client.ShutdownContext(context.Background())
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			t.Require().ErrorIs(err, amplitude.ErrClientShutdown)
+		}
+	}
+
+	t.Require().Equal(1, succeeded)
+	destPlugin.AssertExpectations(t.T())
+}
+
+func (t *ClientSuite) TestTrackAndWait() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code": 200, "events_ingested": 1}`))
+	}))
+	defer server.Close()
+
+	var callbackResults []amplitude.ExecuteResult
+	var callbackResultsMu sync.Mutex
+
+	config := amplitude.NewConfig("your_api_key")
+	config.ServerURL = server.URL
+	config.ExecuteCallback = func(result amplitude.ExecuteResult) {
+		callbackResultsMu.Lock()
+		defer callbackResultsMu.Unlock()
+
+		callbackResults = append(callbackResults, result)
+	}
+
+	client := amplitude.NewExtendedClient(config)
+	defer client.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	require := t.Require()
+
+	result, err := client.TrackAndWait(ctx, t.createEvent(1))
+	require.NoError(err)
+	require.Equal("amplitude", result.PluginName)
+	require.Equal(http.StatusOK, result.Code)
+	require.Equal("event-1", result.Event.EventType)
+
+	event := t.createEvent(2)
+	event.InsertID = ""
+	trackResult, err := client.TrackWithResult(ctx, event)
+	require.NoError(err)
+	client.Flush()
+
+	result, err = trackResult.Wait(ctx)
+	require.NoError(err)
+	require.Equal(http.StatusOK, result.Code)
+	require.Equal("event-2", result.Event.EventType)
+	require.NotEmpty(result.Event.InsertID)
+
+	callbackResultsMu.Lock()
+	defer callbackResultsMu.Unlock()
+	require.Len(callbackResults, 2)
+}
+
+func (t *ClientSuite) TestNewClient_ExtendedClient() {
+	client := amplitude.NewClient(amplitude.NewConfig("your_api_key"))
+	defer client.Shutdown()
+
+	_, ok := client.(amplitude.ExtendedClient)
+	t.Require().True(ok)
+}
+
+func (t *ClientSuite) TestBatchServerURL() {
+	require := t.Require()
+
+	config := amplitude.NewConfig("your_api_key")
+	require.Equal(constants.ServerBatchURLs[amplitude.ServerZoneUS], amplitude.NewExtendedClient(config).Config().BatchServerURL)
+
+	// Batch requests don't bypass a proxy.
+	config.ServerURL = "https://proxy.example.com/2/httpapi"
+	require.Empty(amplitude.NewExtendedClient(config).Config().BatchServerURL)
+
+	config.BatchServerURL = "https://proxy.example.com/batch"
+	require.Equal("https://proxy.example.com/batch", amplitude.NewExtendedClient(config).Config().BatchServerURL)
+}
+
+func (t *ClientSuite) TestResultObserverPlugin() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code": 200, "events_ingested": 1}`))
+	}))
+	defer server.Close()
+
+	config := amplitude.NewConfig("your_api_key")
+	config.ServerURL = server.URL
+
+	client := amplitude.NewExtendedClient(config)
+
+	observer := &testObserverPlugin{}
+	client.Add(observer)
+
+	client.Track(t.createEvent(1))
+	client.Shutdown()
+
+	require := t.Require()
+	require.Len(observer.results, 1)
+	require.Equal(http.StatusOK, observer.results[0].Code)
+	require.Equal("event-1", observer.results[0].Event.EventType)
+}
+
+func (t *ClientSuite) TestTrackWithResult_ResolvedOnShutdown() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+
+	destinationPlugin := &testDestinationPlugin{}
+	destinationPlugin.On("Shutdown").Return()
+	client.Add(destinationPlugin)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	require := t.Require()
+
+	trackResult, err := client.TrackWithResult(ctx, t.createEvent(1))
+	require.NoError(err)
+
+	select {
+	case <-trackResult.Done():
+		require.FailNow("result is resolved without the Amplitude destination")
+	default:
+	}
+
+	client.Shutdown()
+
+	result, err := trackResult.Wait(ctx)
+	require.NoError(err)
+	require.Equal(constants.UnresolvedOnShutdownCode, result.Code)
+	require.Equal("event-1", result.Event.EventType)
+}
+
+func (t *ClientSuite) TestTrackWithResult_Errors() {
+	client := t.createClient(amplitude.NewConfig("your_api_key"))
+	client.Add(&testBeforePlugin{filter: true})
+
+	require := t.Require()
+
+	_, err := client.TrackWithResult(context.Background(), t.createEvent(1))
+	require.ErrorIs(err, amplitude.ErrEventFiltered)
+
+	client.Shutdown()
+	_, err = client.TrackAndWait(context.Background(), t.createEvent(1))
+	require.ErrorIs(err, amplitude.ErrClientShutdown)
+}
+
+func (t *ClientSuite) TestTracing() {
+	tracerProvider := &testTracerProvider{}
+
+	config := amplitude.NewConfig("your_api_key")
+	config.TracerProvider = tracerProvider
+
+	client := t.createClient(config)
+	client.Add(&testBeforePlugin{})
+	client.Add(&testContextDestinationPlugin{err: amplitude.ErrQueueFull})
+
+	require := t.Require()
+	require.Error(client.TrackContext(context.Background(), t.createEvent(1)))
+
+	tracerProvider.mu.Lock()
+	defer tracerProvider.mu.Unlock()
+
+	require.Len(tracerProvider.spans, 3)
+
+	trackSpan, processSpan, enqueueSpan := tracerProvider.spans[0], tracerProvider.spans[1], tracerProvider.spans[2]
+	require.Equal("amplitude.track", trackSpan.name)
+	require.Equal("event-1", trackSpan.attributes["amplitude.event_type"])
+	require.ErrorIs(trackSpan.err, amplitude.ErrQueueFull)
+	require.True(trackSpan.ended)
+	require.Nil(trackSpan.parent)
+
+	require.Equal("amplitude.process", processSpan.name)
+	require.Same(trackSpan, processSpan.parent)
+	require.True(processSpan.ended)
+
+	require.Equal("amplitude.enqueue", enqueueSpan.name)
+	require.Equal("test-context-destination-plugin", enqueueSpan.attributes["amplitude.plugin"])
+	require.ErrorIs(enqueueSpan.err, amplitude.ErrQueueFull)
+	require.Same(trackSpan, enqueueSpan.parent)
+	require.True(enqueueSpan.ended)
+}
+
+func (t *ClientSuite) createClient(config types.Config) amplitude.ExtendedClient {
+	client := amplitude.NewExtendedClient(config)
 	client.Remove("context")
 	client.Remove("amplitude")
 
@@ -279,6 +879,7 @@ func (t *ClientSuite) createEvent(index int) amplitude.Event {
 type testBeforePlugin struct {
 	currentIP  int
 	raisePanic bool
+	filter     bool
 }
 
 func (p *testBeforePlugin) Name() string {
@@ -299,6 +900,10 @@ func (p *testBeforePlugin) Execute(event *amplitude.Event) *amplitude.Event {
 		panic("panic in test-before-plugin")
 	}
 
+	if p.filter {
+		return nil
+	}
+
 	event.IP = "IP " + strconv.Itoa(p.currentIP)
 
 	return event
@@ -329,6 +934,19 @@ func (p *testEnrichmentPlugin) Execute(event *amplitude.Event) *amplitude.Event
 	return event
 }
 
+type testObserverPlugin struct {
+	testBeforePlugin
+	results []amplitude.ExecuteResult
+}
+
+func (p *testObserverPlugin) Name() string {
+	return "test-observer-plugin"
+}
+
+func (p *testObserverPlugin) ObserveResult(result amplitude.ExecuteResult) {
+	p.results = append(p.results, result)
+}
+
 type testDestinationPlugin struct {
 	mock.Mock
 	raisePanic bool
@@ -362,6 +980,90 @@ func (p *testDestinationPlugin) Shutdown() {
 	p.Called()
 }
 
+type testContextDestinationPlugin struct {
+	err     error
+	flushed chan struct{}
+}
+
+func (p *testContextDestinationPlugin) Name() string {
+	return "test-context-destination-plugin"
+}
+
+func (p *testContextDestinationPlugin) Type() amplitude.PluginType {
+	return amplitude.PluginTypeDestination
+}
+
+func (p *testContextDestinationPlugin) Setup(types.Config) {
+}
+
+func (p *testContextDestinationPlugin) Execute(*amplitude.Event) {
+}
+
+func (p *testContextDestinationPlugin) ExecuteContext(context.Context, *amplitude.Event) error {
+	return p.err
+}
+
+func (p *testContextDestinationPlugin) Flush() {
+	if p.flushed != nil {
+		<-p.flushed
+	}
+}
+
+func (p *testContextDestinationPlugin) Shutdown() {
+}
+
+type testSpanKey struct{}
+
+type testTracerProvider struct {
+	spans []*testSpan
+	mu    sync.Mutex
+}
+
+func (p *testTracerProvider) Tracer(string) amplitude.Tracer {
+	return p
+}
+
+func (p *testTracerProvider) Start(ctx context.Context, spanName string) (context.Context, amplitude.Span) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	span := &testSpan{provider: p, name: spanName, attributes: make(map[string]interface{})}
+	span.parent, _ = ctx.Value(testSpanKey{}).(*testSpan)
+	p.spans = append(p.spans, span)
+
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+type testSpan struct {
+	provider   *testTracerProvider
+	name       string
+	parent     *testSpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.provider.mu.Lock()
+	defer s.provider.mu.Unlock()
+
+	s.attributes[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.provider.mu.Lock()
+	defer s.provider.mu.Unlock()
+
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.provider.mu.Lock()
+	defer s.provider.mu.Unlock()
+
+	s.ended = true
+}
+
 type mockLogger struct {
 	mock.Mock
 }
@@ -381,3 +1083,13 @@ func (l *mockLogger) Warnf(message string, args ...interface{}) {
 func (l *mockLogger) Errorf(message string, args ...interface{}) {
 	l.Called(message, args)
 }
+
+type testCurrencyConverter struct {
+	mock.Mock
+}
+
+func (c *testCurrencyConverter) Convert(amount float64, from string, to string) (float64, error) {
+	args := c.Called(amount, from, to)
+
+	return args.Get(0).(float64), args.Error(1)
+}
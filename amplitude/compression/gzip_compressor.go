@@ -0,0 +1,60 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// NewGzipCompressor returns a Compressor using gzip with the default compression level.
+func NewGzipCompressor() types.Compressor {
+	compressor, _ := NewGzipCompressorLevel(gzip.DefaultCompression)
+
+	return compressor
+}
+
+// NewGzipCompressorLevel returns a Compressor using gzip with the given compression level,
+// from gzip.HuffmanOnly to gzip.BestCompression.
+func NewGzipCompressorLevel(level int) (types.Compressor, error) {
+	if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+		return nil, err
+	}
+
+	compressor := &gzipCompressor{}
+	compressor.writers.New = func() interface{} {
+		writer, _ := gzip.NewWriterLevel(nil, level)
+
+		return writer
+	}
+
+	return compressor, nil
+}
+
+type gzipCompressor struct {
+	writers sync.Pool
+}
+
+func (c *gzipCompressor) ContentEncoding() string {
+	return "gzip"
+}
+
+func (c *gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer, _ := c.writers.Get().(*gzip.Writer)
+	defer c.writers.Put(writer)
+
+	writer.Reset(&buffer)
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
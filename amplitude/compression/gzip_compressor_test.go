@@ -0,0 +1,53 @@
+package compression_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/compression"
+)
+
+func TestGzipCompressor(t *testing.T) {
+	suite.Run(t, new(GzipCompressorSuite))
+}
+
+type GzipCompressorSuite struct {
+	suite.Suite
+}
+
+func (t *GzipCompressorSuite) TestCompress() {
+	data := []byte(strings.Repeat(`{"event_type":"event-A","user_id":"user-A"}`, 100))
+
+	compressor := compression.NewGzipCompressor()
+
+	require := t.Require()
+	require.Equal("gzip", compressor.ContentEncoding())
+
+	for i := 0; i < 2; i++ {
+		compressed, err := compressor.Compress(data)
+		require.NoError(err)
+		require.Less(len(compressed), len(data))
+
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(err)
+
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(err)
+		require.Equal(data, decompressed)
+	}
+}
+
+func (t *GzipCompressorSuite) TestCompressorLevel() {
+	require := t.Require()
+
+	_, err := compression.NewGzipCompressorLevel(gzip.BestSpeed)
+	require.NoError(err)
+
+	_, err = compression.NewGzipCompressorLevel(42)
+	require.Error(err)
+}
@@ -16,6 +16,8 @@ const (
 
 	LoggerName = "amplitude"
 
+	AmplitudePluginName = "amplitude"
+
 	RevenueProductID  = "$productId"
 	RevenueQuantity   = "$quantity"
 	RevenuePrice      = "$price"
@@ -25,8 +27,32 @@ const (
 	RevenueReceiptSig = "$receiptSig"
 	DefaultRevenue    = "$revenue"
 
-	MaxPropertyKeys = 1024
-	MaxStringLength = 1024
+	RevenueOrderID          = "order_id"
+	RevenueOriginalCurrency = "original_currency"
+	RevenueOriginalPrice    = "original_price"
+	RevenueOriginalRevenue  = "original_revenue"
+
+	MaxPropertyKeys  = 1024
+	MaxStringLength  = 1024
+	MaxArrayLength   = 1024
+	MaxPropertyDepth = 10
+
+	// Codes reported through ExecuteCallback for events dropped before they were sent.
+	DroppedNewestEventCode       = 1001
+	DroppedOldestEventCode       = 1002
+	DroppedBlockTimeoutEventCode = 1003
+
+	// UnresolvedOnShutdownCode is the code of TrackResults resolved by Shutdown before their event was delivered.
+	UnresolvedOnShutdownCode = 1004
+
+	// InvalidEventCode is reported through ExecuteCallback for events rejected by the validation plugin.
+	InvalidEventCode = 1201
+
+	// TrackingPlanViolationCode is reported through ExecuteCallback for events blocked by the tracking plan plugin.
+	TrackingPlanViolationCode = 1202
+
+	// DefaultMinIDLength is the minimum length of user and device IDs if Config.MinIDLength is not set.
+	DefaultMinIDLength = 5
 )
 
 var ServerURLs = map[types.ServerZone]string{
@@ -39,6 +65,12 @@ var ServerBatchURLs = map[types.ServerZone]string{
 	types.ServerZoneEU: "https://api.eu.amplitude.com/batch",
 }
 
+// Limits of a single request, see https://www.docs.developers.amplitude.com/analytics/apis/.
+var EndpointLimits = map[types.Endpoint]types.EndpointLimits{
+	types.EndpointHTTPAPI: {MaxEvents: 2000, MaxPayloadBytes: 1 << 20},
+	types.EndpointBatch:   {MaxEvents: 2000, MaxPayloadBytes: 20 << 20},
+}
+
 var DefaultConfig = types.Config{
 	FlushInterval:          time.Second * 10,
 	FlushQueueSize:         200,
@@ -49,4 +81,19 @@ var DefaultConfig = types.Config{
 	MaxStorageCapacity:     20000,
 	RetryBaseInterval:      time.Millisecond * 100,
 	RetryThrottledInterval: time.Second * 30,
+
+	QueueFullBlockTimeout: time.Second,
+	StorageLeaseTimeout:   time.Minute,
+
+	FlushSizeRecoverAfter: 10,
+
+	AutoBatchBacklog:  1000,
+	AutoBatchDuration: time.Hour,
+
+	CircuitBreakerOpenTimeout: time.Second * 30,
+
+	MaxArrayLength:   MaxArrayLength,
+	MaxPropertyDepth: MaxPropertyDepth,
+
+	StickyGroupsMaxUsers: 10000,
 }
@@ -0,0 +1,51 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// NewStaticConverter returns a CurrencyConverter using fixed exchange rates,
+// the rate of a currency is the value of one unit of it in a common currency, e.g.
+//
+//	NewStaticConverter(map[string]float64{"USD": 1, "EUR": 1.08, "JPY": 0.0067})
+//
+// Currency codes are case-insensitive.
+func NewStaticConverter(rates map[string]float64) types.CurrencyConverter {
+	converter := &staticConverter{rates: make(map[string]float64, len(rates))}
+
+	for currency, rate := range rates {
+		converter.rates[strings.ToUpper(currency)] = rate
+	}
+
+	return converter
+}
+
+type staticConverter struct {
+	rates map[string]float64
+}
+
+func (c *staticConverter) Convert(amount float64, from string, to string) (float64, error) {
+	fromRate, err := c.rate(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := c.rate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * fromRate / toRate, nil
+}
+
+func (c *staticConverter) rate(currency string) (float64, error) {
+	rate, ok := c.rates[strings.ToUpper(currency)]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for currency %q", currency)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,34 @@
+package currency_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/currency"
+)
+
+func TestStaticConverter(t *testing.T) {
+	suite.Run(t, new(StaticConverterSuite))
+}
+
+type StaticConverterSuite struct {
+	suite.Suite
+}
+
+func (t *StaticConverterSuite) TestConvert() {
+	converter := currency.NewStaticConverter(map[string]float64{"USD": 1, "eur": 1.25, "JPY": 0.01})
+
+	require := t.Require()
+
+	amount, err := converter.Convert(10, "EUR", "USD")
+	require.NoError(err)
+	require.InDelta(12.5, amount, 1e-9)
+
+	amount, err = converter.Convert(-2, "usd", "JPY")
+	require.NoError(err)
+	require.InDelta(-200, amount, 1e-9)
+
+	_, err = converter.Convert(1, "GBP", "USD")
+	require.EqualError(err, `no exchange rate for currency "GBP"`)
+}
@@ -26,3 +26,8 @@ func (b *AtomicBool) UnSet() {
 func (b *AtomicBool) IsSet() bool {
 	return atomic.LoadInt32((*int32)(b)) == 1
 }
+
+// SetIfUnset sets the value and returns true if it was not set, only one of concurrent callers gets true.
+func (b *AtomicBool) SetIfUnset() bool {
+	return atomic.CompareAndSwapInt32((*int32)(b), 0, 1)
+}
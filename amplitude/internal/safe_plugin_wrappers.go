@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
@@ -125,6 +128,10 @@ func (w *SafeDestinationPluginWrapper) Execute(event *types.Event) {
 	w.Plugin.Execute(event)
 }
 
+func (w *SafeDestinationPluginWrapper) ExecuteContext(ctx context.Context, event *types.Event) error {
+	return executeDestinationContext(ctx, w.Plugin, w.isInitialized, w.Logger, event)
+}
+
 type SafeExtendedDestinationPluginWrapper struct {
 	Plugin        types.ExtendedDestinationPlugin
 	Logger        types.Logger
@@ -164,6 +171,10 @@ func (w *SafeExtendedDestinationPluginWrapper) Execute(event *types.Event) {
 	w.Plugin.Execute(event)
 }
 
+func (w *SafeExtendedDestinationPluginWrapper) ExecuteContext(ctx context.Context, event *types.Event) error {
+	return executeDestinationContext(ctx, w.Plugin, w.isInitialized, w.Logger, event)
+}
+
 func (w *SafeExtendedDestinationPluginWrapper) Flush() {
 	if !w.isInitialized {
 		return
@@ -191,3 +202,34 @@ func (w *SafeExtendedDestinationPluginWrapper) Shutdown() {
 
 	w.Plugin.Shutdown()
 }
+
+// executeDestinationContext calls plugin.ExecuteContext if the plugin supports it, otherwise plugin.Execute.
+func executeDestinationContext(
+	ctx context.Context, plugin types.DestinationPlugin, isInitialized bool, logger types.Logger, event *types.Event,
+) (err error) {
+	if !isInitialized {
+		return nil
+	}
+
+	contextPlugin, ok := plugin.(types.ContextDestinationPlugin)
+	method := "Execute"
+
+	if ok {
+		method = "ExecuteContext"
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Panic in plugin %s.%s: %s", plugin.Name(), method, r)
+			err = fmt.Errorf("panic in plugin %s.%s: %s", plugin.Name(), method, r)
+		}
+	}()
+
+	if ok {
+		return contextPlugin.ExecuteContext(ctx, event)
+	}
+
+	plugin.Execute(event)
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package internal_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
@@ -290,6 +291,30 @@ func (t *SafePluginWrappersSuite) TestSafeExtendedDestinationPluginWrapper_Panic
 	logger.AssertExpectations(t.T())
 }
 
+func (t *SafePluginWrappersSuite) TestSafeExtendedDestinationPluginWrapper_PanicOnExecuteContext() {
+	plugin := &testDestinationPlugin{raisePanicOnExecute: true}
+	logger := &mockLogger{}
+	wrapper := internal.SafeExtendedDestinationPluginWrapper{
+		Plugin: plugin,
+		Logger: logger,
+	}
+
+	logger.On("Errorf", "Panic in plugin %s.%s: %s", []interface{}{"test-destination-plugin", "Execute", "panic in test-destination-plugin"}).Return().Once()
+
+	config := types.Config{}
+	plugin.On("Setup", config).Once()
+	wrapper.Setup(config)
+
+	event := &types.Event{}
+	plugin.On("Execute", event).Once()
+	err := wrapper.ExecuteContext(context.Background(), event)
+
+	t.Require().EqualError(err, "panic in plugin test-destination-plugin.Execute: panic in test-destination-plugin")
+
+	plugin.AssertExpectations(t.T())
+	logger.AssertExpectations(t.T())
+}
+
 func (t *SafePluginWrappersSuite) TestSafeExtendedDestinationPluginWrapper_PanicOnFlush() {
 	plugin := &testDestinationPlugin{raisePanicOnFlush: true}
 	logger := &mockLogger{}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+)
+
+// UserGroups keeps the groups of users, the groups of the least recently changed user are evicted first.
+type UserGroups struct {
+	maxUsers int
+
+	mu    sync.Mutex
+	users map[string]*list.Element
+	lru   *list.List
+}
+
+type userGroupsEntry struct {
+	key    string
+	groups map[string][]string
+}
+
+func NewUserGroups(maxUsers int) *UserGroups {
+	return &UserGroups{
+		maxUsers: maxUsers,
+		users:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Set replaces the group names of a group type of the user.
+func (g *UserGroups) Set(key string, groupType string, groupNames []string) {
+	g.update(key, groupType, func([]string) []string {
+		return append([]string(nil), groupNames...)
+	})
+}
+
+// Add adds group names to a group type of the user, names the user already has are skipped.
+func (g *UserGroups) Add(key string, groupType string, groupNames []string) {
+	g.update(key, groupType, func(current []string) []string {
+		result := append([]string(nil), current...)
+
+		for _, groupName := range groupNames {
+			if !containsString(result, groupName) {
+				result = append(result, groupName)
+			}
+		}
+
+		return result
+	})
+}
+
+// Remove removes group names from a group type of the user.
+func (g *UserGroups) Remove(key string, groupType string, groupNames []string) {
+	g.update(key, groupType, func(current []string) []string {
+		var result []string
+
+		for _, groupName := range current {
+			if !containsString(groupNames, groupName) {
+				result = append(result, groupName)
+			}
+		}
+
+		return result
+	})
+}
+
+// Get returns a copy of the groups of the user, or nil if the user has none.
+func (g *UserGroups) Get(key string) map[string][]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	element, ok := g.users[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*userGroupsEntry)
+	groups := make(map[string][]string, len(entry.groups))
+
+	for groupType, groupNames := range entry.groups {
+		groups[groupType] = append([]string(nil), groupNames...)
+	}
+
+	return groups
+}
+
+func (g *UserGroups) update(key string, groupType string, fn func(current []string) []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	element, ok := g.users[key]
+	if ok {
+		g.lru.MoveToFront(element)
+	} else {
+		if g.lru.Len() >= g.maxUsers {
+			oldest := g.lru.Back()
+			g.lru.Remove(oldest)
+			delete(g.users, oldest.Value.(*userGroupsEntry).key)
+		}
+
+		element = g.lru.PushFront(&userGroupsEntry{key: key, groups: make(map[string][]string)})
+		g.users[key] = element
+	}
+
+	entry := element.Value.(*userGroupsEntry)
+
+	groupNames := fn(entry.groups[groupType])
+	if len(groupNames) > 0 {
+		entry.groups[groupType] = groupNames
+	} else {
+		delete(entry.groups, groupType)
+	}
+
+	if len(entry.groups) == 0 {
+		g.lru.Remove(element)
+		delete(g.users, key)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
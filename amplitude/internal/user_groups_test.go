@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/internal"
+)
+
+func TestUserGroups(t *testing.T) {
+	suite.Run(t, new(UserGroupsSuite))
+}
+
+type UserGroupsSuite struct {
+	suite.Suite
+}
+
+func (t *UserGroupsSuite) TestSetAddRemove() {
+	groups := internal.NewUserGroups(10)
+
+	require := t.Require()
+	require.Nil(groups.Get("user:u1"))
+
+	groups.Set("user:u1", "workspace", []string{"acme"})
+	groups.Add("user:u1", "workspace", []string{"globex", "acme"})
+	groups.Set("user:u1", "plan", []string{"enterprise"})
+	require.Equal(map[string][]string{"workspace": {"acme", "globex"}, "plan": {"enterprise"}}, groups.Get("user:u1"))
+
+	groups.Remove("user:u1", "workspace", []string{"acme"})
+	groups.Remove("user:u1", "plan", []string{"enterprise"})
+	require.Equal(map[string][]string{"workspace": {"globex"}}, groups.Get("user:u1"))
+
+	groups.Remove("user:u1", "workspace", []string{"globex"})
+	require.Nil(groups.Get("user:u1"))
+}
+
+func (t *UserGroupsSuite) TestGetReturnsCopy() {
+	groups := internal.NewUserGroups(10)
+
+	names := []string{"acme"}
+	groups.Set("user:u1", "workspace", names)
+	names[0] = "changed"
+
+	result := groups.Get("user:u1")
+	result["workspace"][0] = "changed"
+
+	t.Require().Equal(map[string][]string{"workspace": {"acme"}}, groups.Get("user:u1"))
+}
+
+func (t *UserGroupsSuite) TestEviction() {
+	groups := internal.NewUserGroups(2)
+
+	groups.Set("user:u1", "workspace", []string{"acme"})
+	groups.Set("user:u2", "workspace", []string{"acme"})
+	groups.Add("user:u1", "workspace", []string{"globex"})
+	groups.Set("user:u3", "workspace", []string{"acme"})
+
+	require := t.Require()
+	require.NotNil(groups.Get("user:u1"))
+	require.Nil(groups.Get("user:u2"), "least recently changed user is evicted")
+	require.NotNil(groups.Get("user:u3"))
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// Names of metrics emitted by the SDK pipeline.
+const (
+	// EventsTrackedTotal counts events processed by the client timeline.
+	EventsTrackedTotal = "amplitude_events_tracked_total"
+	// EventsFilteredTotal counts events dropped by before or enrichment plugins, labeled by stage.
+	EventsFilteredTotal = "amplitude_events_filtered_total"
+	// EventsDroppedTotal counts events dropped by the Amplitude destination before sending, labeled by reason.
+	EventsDroppedTotal = "amplitude_events_dropped_total"
+	// QueueDepth is the number of events waiting in the Amplitude destination queue.
+	QueueDepth = "amplitude_queue_depth"
+	// StorageDepth is the number of events in storage ready to be sent.
+	StorageDepth = "amplitude_storage_events"
+	// PullSize observes the number of events pulled from storage for one request.
+	PullSize = "amplitude_pull_size"
+	// ChunkSize is the current maximum number of events sent in one request.
+	ChunkSize = "amplitude_chunk_size"
+	// ChunkSizeReductionsTotal counts chunk size reductions after 413 responses.
+	ChunkSizeReductionsTotal = "amplitude_chunk_size_reductions_total"
+	// ChunkSizeRecoveriesTotal counts chunk size increases after successful requests.
+	ChunkSizeRecoveriesTotal = "amplitude_chunk_size_recoveries_total"
+	// HTTPRequestsTotal counts HTTP requests, labeled by status.
+	HTTPRequestsTotal = "amplitude_http_requests_total"
+	// HTTPRequestDuration observes HTTP request latency in seconds, labeled by status.
+	HTTPRequestDuration = "amplitude_http_request_duration_seconds"
+	// EventsSentTotal counts events that will not be retried, labeled by result and code.
+	EventsSentTotal = "amplitude_events_sent_total"
+	// EventsRetriedTotal counts events scheduled for retry.
+	EventsRetriedTotal = "amplitude_events_retried_total"
+	// CircuitBreakerState is the state of the circuit breaker: 0 closed, 1 open, 2 half-open.
+	CircuitBreakerState = "amplitude_circuit_breaker_state"
+	// EventsThrottledTotal counts events delayed because their user or device was throttled.
+	EventsThrottledTotal = "amplitude_events_throttled_total"
+	// EventsHeldTotal counts events held back until earlier events of their user or device are retried.
+	EventsHeldTotal = "amplitude_events_held_total"
+	// IdentifiesCoalescedTotal counts identify events merged into other identify events of their user or device.
+	IdentifiesCoalescedTotal = "amplitude_identifies_coalesced_total"
+)
+
+// NewNoopMetrics returns Metrics that discard everything.
+func NewNoopMetrics() types.Metrics {
+	return noopMetrics{}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) AddCounter(string, float64, map[string]string) {
+}
+
+func (noopMetrics) SetGauge(string, float64, map[string]string) {
+}
+
+func (noopMetrics) ObserveHistogram(string, float64, map[string]string) {
+}
@@ -0,0 +1,259 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// DefaultBuckets are upper bounds of histograms without configured buckets, suited for latencies in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultPullSizeBuckets are upper bounds of the PullSize histogram.
+var DefaultPullSizeBuckets = []float64{1, 10, 50, 100, 200, 500, 1000, 2000}
+
+// PrometheusMetrics collects metrics and serves them in the Prometheus text exposition format.
+type PrometheusMetrics interface {
+	types.Metrics
+	http.Handler
+}
+
+type PrometheusMetricsOptions struct {
+	// Buckets are histogram upper bounds by metric name.
+	// Histograms without buckets use DefaultPullSizeBuckets for PullSize and DefaultBuckets otherwise.
+	Buckets map[string][]float64
+}
+
+// NewPrometheusMetrics returns Metrics to be set in Config and served by an HTTP server, e.g.
+//
+//	http.Handle("/metrics", prometheusMetrics)
+//
+// A metric name keeps the type it was first used with, later uses with another type are ignored.
+func NewPrometheusMetrics(options PrometheusMetricsOptions) PrometheusMetrics {
+	return &prometheusMetrics{
+		options:  options,
+		families: make(map[string]*metricFamily),
+	}
+}
+
+const (
+	metricTypeCounter   = "counter"
+	metricTypeGauge     = "gauge"
+	metricTypeHistogram = "histogram"
+)
+
+type prometheusMetrics struct {
+	options  PrometheusMetricsOptions
+	families map[string]*metricFamily
+	mu       sync.Mutex
+}
+
+type metricFamily struct {
+	metricType string
+	series     map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labels []labelPair
+	value  float64
+
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+func (m *prometheusMetrics) AddCounter(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if series := m.series(name, metricTypeCounter, labels); series != nil {
+		series.value += value
+	}
+}
+
+func (m *prometheusMetrics) SetGauge(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if series := m.series(name, metricTypeGauge, labels); series != nil {
+		series.value = value
+	}
+}
+
+func (m *prometheusMetrics) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	series := m.series(name, metricTypeHistogram, labels)
+	if series == nil {
+		return
+	}
+
+	if series.bounds == nil {
+		series.bounds = m.buckets(name)
+		series.counts = make([]uint64, len(series.bounds))
+	}
+
+	for i, bound := range series.bounds {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+
+	series.sum += value
+	series.count++
+}
+
+func (m *prometheusMetrics) series(name string, metricType string, labels map[string]string) *metricSeries {
+	family, ok := m.families[name]
+	if !ok {
+		family = &metricFamily{
+			metricType: metricType,
+			series:     make(map[string]*metricSeries),
+		}
+		m.families[name] = family
+	} else if family.metricType != metricType {
+		return nil
+	}
+
+	pairs := make([]labelPair, 0, len(labels))
+	for labelName, labelValue := range labels {
+		pairs = append(pairs, labelPair{name: labelName, value: labelValue})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].name < pairs[j].name
+	})
+
+	key := formatLabels(pairs)
+
+	series, ok := family.series[key]
+	if !ok {
+		series = &metricSeries{labels: pairs}
+		family.series[key] = series
+	}
+
+	return series
+}
+
+func (m *prometheusMetrics) buckets(name string) []float64 {
+	buckets, ok := m.options.Buckets[name]
+	if !ok {
+		if name == PullSize {
+			buckets = DefaultPullSizeBuckets
+		} else {
+			buckets = DefaultBuckets
+		}
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return buckets
+}
+
+func (m *prometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	// Metrics are rendered before writing the response, so a slow scraper doesn't block metric updates.
+	var buffer bytes.Buffer
+
+	m.write(&buffer)
+
+	_, _ = buffer.WriteTo(w)
+}
+
+func (m *prometheusMetrics) write(writer *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := m.families[name]
+		fmt.Fprintf(writer, "# TYPE %s %s\n", name, family.metricType)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			series := family.series[key]
+
+			if family.metricType != metricTypeHistogram {
+				fmt.Fprintf(writer, "%s%s %s\n", name, key, formatFloat(series.value))
+
+				continue
+			}
+
+			for i, bound := range series.bounds {
+				bucketLabels := append(series.labels[:len(series.labels):len(series.labels)], labelPair{name: "le", value: formatFloat(bound)})
+				fmt.Fprintf(writer, "%s_bucket%s %d\n", name, formatLabels(bucketLabels), series.counts[i])
+			}
+
+			infLabels := append(series.labels[:len(series.labels):len(series.labels)], labelPair{name: "le", value: "+Inf"})
+			fmt.Fprintf(writer, "%s_bucket%s %d\n", name, formatLabels(infLabels), series.count)
+			fmt.Fprintf(writer, "%s_sum%s %s\n", name, key, formatFloat(series.sum))
+			fmt.Fprintf(writer, "%s_count%s %d\n", name, key, series.count)
+		}
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []labelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	builder.WriteByte('{')
+
+	for i, label := range labels {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+
+		builder.WriteString(label.name)
+		builder.WriteString(`="`)
+		builder.WriteString(labelValueReplacer.Replace(label.value))
+		builder.WriteByte('"')
+	}
+
+	builder.WriteByte('}')
+
+	return builder.String()
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
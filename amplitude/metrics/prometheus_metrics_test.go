@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/metrics"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	suite.Run(t, new(PrometheusMetricsSuite))
+}
+
+type PrometheusMetricsSuite struct {
+	suite.Suite
+}
+
+func (t *PrometheusMetricsSuite) TestServeHTTP() {
+	m := metrics.NewPrometheusMetrics(metrics.PrometheusMetricsOptions{
+		Buckets: map[string][]float64{
+			metrics.HTTPRequestDuration: {0.5, 0.1},
+		},
+	})
+
+	m.AddCounter(metrics.EventsDroppedTotal, 1, map[string]string{"reason": "queue_full"})
+	m.AddCounter(metrics.EventsDroppedTotal, 2, map[string]string{"reason": "queue_full"})
+	m.AddCounter(metrics.EventsDroppedTotal, 1, map[string]string{"reason": `a "b"`})
+	m.SetGauge(metrics.QueueDepth, 5, nil)
+	m.SetGauge(metrics.QueueDepth, 3, nil)
+	m.ObserveHistogram(metrics.HTTPRequestDuration, 0.05, map[string]string{"status": "200"})
+	m.ObserveHistogram(metrics.HTTPRequestDuration, 0.3, map[string]string{"status": "200"})
+
+	// A name keeps the type it was first used with.
+	m.SetGauge(metrics.EventsDroppedTotal, 100, nil)
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(recorder.Result().Body)
+
+	require := t.Require()
+	require.NoError(err)
+	require.Equal("text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	require.Equal(`# TYPE amplitude_events_dropped_total counter
+amplitude_events_dropped_total{reason="a \"b\""} 1
+amplitude_events_dropped_total{reason="queue_full"} 3
+# TYPE amplitude_http_request_duration_seconds histogram
+amplitude_http_request_duration_seconds_bucket{status="200",le="0.1"} 1
+amplitude_http_request_duration_seconds_bucket{status="200",le="0.5"} 2
+amplitude_http_request_duration_seconds_bucket{status="200",le="+Inf"} 2
+amplitude_http_request_duration_seconds_sum{status="200"} 0.35
+amplitude_http_request_duration_seconds_count{status="200"} 2
+# TYPE amplitude_queue_depth gauge
+amplitude_queue_depth 3
+`, string(body))
+}
+
+func (t *PrometheusMetricsSuite) TestNoopMetrics() {
+	m := metrics.NewNoopMetrics()
+
+	t.Require().NotPanics(func() {
+		m.AddCounter(metrics.EventsTrackedTotal, 1, nil)
+		m.SetGauge(metrics.QueueDepth, 1, nil)
+		m.ObserveHistogram(metrics.PullSize, 1, nil)
+	})
+}
@@ -0,0 +1,266 @@
+package before
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+type IdentifyCachePluginOptions struct {
+	// MaxUsers is the number of users whose properties are cached, the least recently identified user is evicted first.
+	// It defaults to 10000.
+	MaxUsers int
+
+	// TTL is how long a sent property value suppresses the same value, it defaults to one hour.
+	TTL time.Duration
+	Now func() time.Time
+}
+
+// IdentifyCachePlugin is a Before plugin that remembers the user properties delivered by identify events of each user,
+// and removes $set and $setOnce operations that wouldn't change them. An identify event left without operations is dropped.
+// Other operations are always sent, and make the cached value of their property unknown.
+// Values are cached once the Amplitude destination reports the event delivered, and forgotten if it reports a failure,
+// so the same value tracked again before the first one is delivered is sent again.
+// It's notified of delivery results as a ResultObserverPlugin added to the client.
+type IdentifyCachePlugin struct {
+	options IdentifyCachePluginOptions
+	logger  types.Logger
+
+	mu    sync.Mutex
+	users map[string]*list.Element
+	lru   *list.List
+}
+
+type identifyCacheEntry struct {
+	key        string
+	properties map[string]cachedProperty
+}
+
+// cachedProperty is a sent property value encoded as JSON, so 1 and 1.0 are equal and later changes of the value don't matter.
+// The value is empty if the property has an unknown value.
+type cachedProperty struct {
+	value     string
+	expiresAt time.Time
+}
+
+func NewIdentifyCachePlugin(options IdentifyCachePluginOptions) types.BeforePlugin {
+	if options.MaxUsers <= 0 {
+		options.MaxUsers = 10000
+	}
+
+	if options.TTL <= 0 {
+		options.TTL = time.Hour
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &IdentifyCachePlugin{
+		options: options,
+		users:   make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (p *IdentifyCachePlugin) Name() string {
+	return "identify-cache"
+}
+
+func (p *IdentifyCachePlugin) Type() types.PluginType {
+	return types.PluginTypeBefore
+}
+
+func (p *IdentifyCachePlugin) Setup(config types.Config) {
+	p.logger = config.Logger
+}
+
+// Execute removes unchanged $set and $setOnce operations of identify events.
+// It returns nil if no operation is left.
+func (p *IdentifyCachePlugin) Execute(event *types.Event) *types.Event {
+	if event.EventType != constants.IdentifyEventType || len(event.UserProperties) == 0 {
+		return event
+	}
+
+	key := identifyCacheKey(event)
+	if key == "" {
+		return event
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := event.UserProperties[types.IdentityOpClearAll]; ok {
+		p.remove(key)
+
+		return event
+	}
+
+	entry := p.entry(key)
+	now := p.options.Now()
+
+	// The maps of the event may be shared with the Identify of the caller, so they are replaced instead of changed.
+	userProperties := make(map[types.IdentityOp]map[string]interface{}, len(event.UserProperties))
+	suppressed := 0
+
+	for operation, properties := range event.UserProperties {
+		kept := make(map[string]interface{}, len(properties))
+
+		for property, value := range properties {
+			if p.unchanged(entry, operation, property, value, now) {
+				suppressed++
+
+				continue
+			}
+
+			kept[property] = value
+		}
+
+		if len(kept) > 0 {
+			userProperties[operation] = kept
+		}
+	}
+
+	if suppressed == 0 {
+		return event
+	}
+
+	if len(userProperties) == 0 {
+		p.logger.Debugf("Identify of %s is dropped, user properties are unchanged", key)
+
+		return nil
+	}
+
+	event.UserProperties = userProperties
+
+	return event
+}
+
+// unchanged reports whether the operation would not change the cached property.
+// Operations other than $set and $setOnce make the cached value unknown right away.
+func (p *IdentifyCachePlugin) unchanged(
+	entry *identifyCacheEntry, operation types.IdentityOp, property string, value interface{}, now time.Time,
+) bool {
+	cached, ok := entry.properties[property]
+	if ok && !now.Before(cached.expiresAt) {
+		delete(entry.properties, property)
+
+		ok = false
+	}
+
+	switch operation {
+	case types.IdentityOpSet:
+		encoded, err := json.Marshal(value)
+
+		return err == nil && ok && cached.value == string(encoded)
+	case types.IdentityOpSetOnce:
+		// $setOnce is ignored by the server once the property has a value.
+		return ok
+	default:
+		delete(entry.properties, property)
+
+		return false
+	}
+}
+
+// ObserveResult caches the user properties of an identify event delivered by the Amplitude destination,
+// and forgets the cached values of its properties if the event failed.
+func (p *IdentifyCachePlugin) ObserveResult(result types.ExecuteResult) {
+	event := result.Event
+	if event == nil || event.EventType != constants.IdentifyEventType || len(event.UserProperties) == 0 {
+		return
+	}
+
+	key := identifyCacheKey(event)
+	if key == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delivered := result.Code >= 200 && result.Code < 300
+
+	if _, ok := event.UserProperties[types.IdentityOpClearAll]; ok || !delivered {
+		if element, ok := p.users[key]; ok {
+			entry := element.Value.(*identifyCacheEntry)
+
+			for _, properties := range event.UserProperties {
+				for property := range properties {
+					delete(entry.properties, property)
+				}
+			}
+		}
+
+		return
+	}
+
+	entry := p.entry(key)
+	expiresAt := p.options.Now().Add(p.options.TTL)
+
+	for operation, properties := range event.UserProperties {
+		for property, value := range properties {
+			switch operation {
+			case types.IdentityOpSet:
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					delete(entry.properties, property)
+
+					continue
+				}
+
+				entry.properties[property] = cachedProperty{value: string(encoded), expiresAt: expiresAt}
+			case types.IdentityOpSetOnce:
+				// The value $setOnce leaves is unknown, since it may be ignored.
+				if _, ok := entry.properties[property]; !ok {
+					entry.properties[property] = cachedProperty{expiresAt: expiresAt}
+				}
+			default:
+				delete(entry.properties, property)
+			}
+		}
+	}
+}
+
+// identifyCacheKey returns the key of the user of the event in the cache, or an empty string if it has no IDs.
+func identifyCacheKey(event *types.Event) string {
+	userID, deviceID := eventIDs(event)
+
+	switch {
+	case userID != "":
+		return "user:" + userID
+	case deviceID != "":
+		return "device:" + deviceID
+	default:
+		return ""
+	}
+}
+
+// entry returns the cache entry of the user, creating it and evicting the least recently used one if needed.
+func (p *IdentifyCachePlugin) entry(key string) *identifyCacheEntry {
+	if element, ok := p.users[key]; ok {
+		p.lru.MoveToFront(element)
+
+		return element.Value.(*identifyCacheEntry)
+	}
+
+	if p.lru.Len() >= p.options.MaxUsers {
+		p.remove(p.lru.Back().Value.(*identifyCacheEntry).key)
+	}
+
+	entry := &identifyCacheEntry{key: key, properties: make(map[string]cachedProperty)}
+	p.users[key] = p.lru.PushFront(entry)
+
+	return entry
+}
+
+func (p *IdentifyCachePlugin) remove(key string) {
+	if element, ok := p.users[key]; ok {
+		p.lru.Remove(element)
+		delete(p.users, key)
+	}
+}
@@ -0,0 +1,170 @@
+package before_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/loggers"
+	"github.com/amplitude/analytics-go/amplitude/plugins/before"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestIdentifyCachePlugin(t *testing.T) {
+	suite.Run(t, new(IdentifyCachePluginSuite))
+}
+
+type IdentifyCachePluginSuite struct {
+	suite.Suite
+}
+
+func (t *IdentifyCachePluginSuite) TestSuppressUnchangedSet() {
+	now := time.Now()
+	plugin := t.setupPlugin(before.IdentifyCachePluginOptions{TTL: time.Minute, Now: func() time.Time { return now }})
+
+	require := t.Require()
+
+	properties := map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro", "seats": 1},
+		types.IdentityOpAdd: {"visits": 1},
+	}
+	first := t.createIdentify("user-1", properties)
+	require.Same(first, t.execute(plugin, first))
+	require.Equal(properties, first.UserProperties)
+
+	second := t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro", "seats": 1.0, "name": "user-A"},
+		types.IdentityOpAdd: {"visits": 1},
+	})
+	require.Same(second, t.execute(plugin, second))
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"name": "user-A"},
+		types.IdentityOpAdd: {"visits": 1},
+	}, second.UserProperties)
+	require.Equal(map[string]interface{}{"plan": "pro", "seats": 1}, properties[types.IdentityOpSet], "maps of the caller are unchanged")
+
+	require.Nil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro"},
+	})))
+
+	changed := t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "free"},
+	})
+	require.Same(changed, t.execute(plugin, changed))
+	require.Equal("free", changed.UserProperties[types.IdentityOpSet]["plan"])
+
+	other := t.createIdentify("user-2", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "free"},
+	})
+	require.Same(other, t.execute(plugin, other))
+
+	now = now.Add(time.Minute)
+
+	expired := t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "free"},
+	})
+	require.Same(expired, t.execute(plugin, expired))
+}
+
+func (t *IdentifyCachePluginSuite) TestOtherOperations() {
+	plugin := t.setupPlugin(before.IdentifyCachePluginOptions{})
+
+	require := t.Require()
+
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet:     {"plan": "pro", "tags": []string{"a"}},
+		types.IdentityOpSetOnce: {"signup": "2023-01-01"},
+	})))
+
+	require.Nil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSetOnce: {"signup": "2023-02-01", "plan": "free"},
+	})), "$setOnce is suppressed for properties with a value")
+
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"signup": "2023-01-01"},
+	})), "the value of $setOnce is unknown")
+
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpAppend: {"tags": "b"},
+	})))
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"tags": []string{"a"}},
+	})), "$append makes the cached value unknown")
+
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpClearAll: {types.UnsetValue: nil},
+	})))
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro"},
+	})), "$clearAll clears the cache of the user")
+
+	track := &types.Event{EventType: "event-A", EventOptions: types.EventOptions{UserID: "user-1"}}
+	require.Same(track, t.execute(plugin, track))
+}
+
+func (t *IdentifyCachePluginSuite) TestEviction() {
+	plugin := t.setupPlugin(before.IdentifyCachePluginOptions{MaxUsers: 2})
+
+	set := map[types.IdentityOp]map[string]interface{}{types.IdentityOpSet: {"plan": "pro"}}
+
+	require := t.Require()
+	require.NotNil(t.execute(plugin, t.createIdentify("user-1", set)))
+	require.NotNil(t.execute(plugin, t.createIdentify("user-2", set)))
+	require.Nil(t.execute(plugin, t.createIdentify("user-1", set)))
+	require.NotNil(t.execute(plugin, t.createIdentify("user-3", set)), "user-2 is evicted")
+	require.Nil(t.execute(plugin, t.createIdentify("user-1", set)))
+	require.NotNil(t.execute(plugin, t.createIdentify("user-2", set)))
+}
+
+func (t *IdentifyCachePluginSuite) TestCacheDeliveredValues() {
+	plugin := t.setupPlugin(before.IdentifyCachePluginOptions{})
+	observer := plugin.(types.ResultObserverPlugin)
+
+	set := map[types.IdentityOp]map[string]interface{}{types.IdentityOpSet: {"plan": "pro"}}
+
+	require := t.Require()
+
+	first := t.createIdentify("user-1", set)
+	require.Same(first, plugin.Execute(first))
+	require.NotNil(plugin.Execute(t.createIdentify("user-1", set)), "the value isn't delivered yet")
+
+	observer.ObserveResult(types.ExecuteResult{Event: first, Code: 200})
+	require.Nil(plugin.Execute(t.createIdentify("user-1", set)))
+
+	failed := t.createIdentify("user-1", map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "free"},
+	})
+	require.Same(failed, plugin.Execute(failed))
+	observer.ObserveResult(types.ExecuteResult{Event: failed, Code: 400})
+
+	require.NotNil(plugin.Execute(t.createIdentify("user-1", set)), "the failed event makes the value unknown")
+}
+
+func (t *IdentifyCachePluginSuite) setupPlugin(options before.IdentifyCachePluginOptions) types.BeforePlugin {
+	plugin := before.NewIdentifyCachePlugin(options)
+	plugin.Setup(types.Config{Logger: loggers.NewDefaultLogger()})
+
+	return plugin
+}
+
+// execute executes the event and reports it delivered if it isn't dropped.
+func (t *IdentifyCachePluginSuite) execute(plugin types.BeforePlugin, event *types.Event) *types.Event {
+	result := plugin.Execute(event)
+	if result != nil {
+		plugin.(types.ResultObserverPlugin).ObserveResult(types.ExecuteResult{Event: result, Code: 200})
+	}
+
+	return result
+}
+
+func (t *IdentifyCachePluginSuite) createIdentify(
+	userID string, properties map[types.IdentityOp]map[string]interface{},
+) *types.Event {
+	return &types.Event{
+		EventType:      constants.IdentifyEventType,
+		EventOptions:   types.EventOptions{UserID: userID},
+		UserProperties: properties,
+	}
+}
@@ -0,0 +1,187 @@
+package before
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/trackingplan"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+const defaultAnnotationProperty = "tracking_plan_violations"
+
+type TrackingPlanPluginOptions struct {
+	// Plan is the tracking plan, see trackingplan.Load.
+	Plan *types.TrackingPlan
+	Mode types.TrackingPlanMode
+
+	// AllowUnplannedEvents accepts events that are not in the tracking plan.
+	AllowUnplannedEvents bool
+
+	// AnnotationProperty is the event property listing violations with TrackingPlanModeAnnotate,
+	// it defaults to "tracking_plan_violations".
+	AnnotationProperty string
+}
+
+// TrackingPlanReport summarizes the events checked by the tracking plan plugin.
+// Violations counts the events by violation, e.g. `Song Played: property "genre" is required`.
+type TrackingPlanReport struct {
+	CheckedEvents int
+	InvalidEvents int
+	BlockedEvents int
+	Violations    map[string]int
+}
+
+// TrackingPlanPlugin is a Before plugin that checks tracked events against a tracking plan.
+type TrackingPlanPlugin interface {
+	types.BeforePlugin
+
+	// Report returns the summary of the events checked since the plugin was created.
+	Report() TrackingPlanReport
+}
+
+type trackingPlanPlugin struct {
+	options         TrackingPlanPluginOptions
+	checker         trackingplan.Checker
+	logger          types.Logger
+	executeCallback func(result types.ExecuteResult)
+
+	// plan is set on events without Plan if Config.Plan is not set.
+	plan *types.Plan
+
+	mu     sync.Mutex
+	report TrackingPlanReport
+}
+
+func NewTrackingPlanPlugin(options TrackingPlanPluginOptions) TrackingPlanPlugin {
+	if options.Plan == nil {
+		options.Plan = &types.TrackingPlan{}
+	}
+
+	if options.AnnotationProperty == "" {
+		options.AnnotationProperty = defaultAnnotationProperty
+	}
+
+	return &trackingPlanPlugin{
+		options: options,
+		checker: trackingplan.NewChecker(options.Plan, trackingplan.CheckerOptions{
+			AllowUnplannedEvents: options.AllowUnplannedEvents,
+		}),
+		report: TrackingPlanReport{Violations: make(map[string]int)},
+	}
+}
+
+func (p *trackingPlanPlugin) Name() string {
+	return "tracking-plan"
+}
+
+func (p *trackingPlanPlugin) Type() types.PluginType {
+	return types.PluginTypeBefore
+}
+
+// Setup compares the tracking plan with Config.Plan, which is expected to describe the same version.
+func (p *trackingPlanPlugin) Setup(config types.Config) {
+	p.logger = config.Logger
+	p.executeCallback = config.ExecuteCallback
+
+	plan := p.options.Plan.Plan
+
+	switch {
+	case plan == (types.Plan{}):
+	case config.Plan == nil:
+		p.plan = &plan
+	case config.Plan.Branch != plan.Branch || config.Plan.Version != plan.Version:
+		p.logger.Warnf("Tracking plan %s@%s doesn't match Config.Plan %s@%s",
+			plan.Branch, plan.Version, config.Plan.Branch, config.Plan.Version)
+	}
+}
+
+// Execute checks the event and applies the mode if it violates the tracking plan.
+// It returns nil if the event is blocked.
+func (p *trackingPlanPlugin) Execute(event *types.Event) *types.Event {
+	if event.Plan == nil && p.plan != nil {
+		plan := *p.plan
+		event.Plan = &plan
+	}
+
+	violations := p.checker.Check(event)
+	p.record(violations)
+
+	if len(violations) == 0 {
+		return event
+	}
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.String()
+	}
+
+	switch p.options.Mode {
+	case types.TrackingPlanModeLog:
+		p.logger.Warnf("Event violates the tracking plan: %s", strings.Join(messages, "; "))
+	case types.TrackingPlanModeAnnotate:
+		// The event properties may be shared with the caller, so they are replaced instead of changed.
+		eventProperties := make(map[string]interface{}, len(event.EventProperties)+1)
+		for property, value := range event.EventProperties {
+			eventProperties[property] = value
+		}
+
+		eventProperties[p.options.AnnotationProperty] = messages
+		event.EventProperties = eventProperties
+	case types.TrackingPlanModeBlock:
+		p.block(event, messages)
+
+		return nil
+	}
+
+	return event
+}
+
+func (p *trackingPlanPlugin) block(event *types.Event, messages []string) {
+	p.mu.Lock()
+	p.report.BlockedEvents++
+	p.mu.Unlock()
+
+	p.logger.Errorf("Event violating the tracking plan is blocked: %s", strings.Join(messages, "; "))
+
+	if p.executeCallback == nil {
+		return
+	}
+
+	p.executeCallback(types.ExecuteResult{
+		PluginName: p.Name(),
+		Event:      event,
+		Code:       constants.TrackingPlanViolationCode,
+		Message:    "Tracking plan violation: " + strings.Join(messages, "; "),
+	})
+}
+
+func (p *trackingPlanPlugin) record(violations []trackingplan.Violation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.report.CheckedEvents++
+
+	if len(violations) > 0 {
+		p.report.InvalidEvents++
+	}
+
+	for _, violation := range violations {
+		p.report.Violations[violation.String()]++
+	}
+}
+
+func (p *trackingPlanPlugin) Report() TrackingPlanReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := p.report
+	report.Violations = make(map[string]int, len(p.report.Violations))
+
+	for violation, count := range p.report.Violations {
+		report.Violations[violation] = count
+	}
+
+	return report
+}
@@ -0,0 +1,115 @@
+package before_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/loggers"
+	"github.com/amplitude/analytics-go/amplitude/plugins/before"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestTrackingPlanPlugin(t *testing.T) {
+	suite.Run(t, new(TrackingPlanPluginSuite))
+}
+
+type TrackingPlanPluginSuite struct {
+	suite.Suite
+}
+
+var trackingPlan = &types.TrackingPlan{
+	Plan: types.Plan{Branch: "main", Version: "3"},
+	Events: []types.TrackingPlanEvent{{
+		Name: "Song Played",
+		Properties: []types.TrackingPlanProperty{
+			{Name: "genre", Type: types.PropertyTypeString, Required: true},
+		},
+	}},
+}
+
+func (t *TrackingPlanPluginSuite) TestLog() {
+	plugin, results := t.setupPlugin(types.TrackingPlanModeLog, nil)
+
+	valid := &types.Event{EventType: "Song Played", EventProperties: map[string]interface{}{"genre": "rock"}}
+	invalid := &types.Event{EventType: "Song Played"}
+
+	require := t.Require()
+	require.Same(valid, plugin.Execute(valid))
+	require.Same(invalid, plugin.Execute(invalid))
+	require.Nil(invalid.EventProperties)
+	require.Empty(*results)
+
+	require.Equal(&types.Plan{Branch: "main", Version: "3"}, invalid.Plan, "plan is set without Config.Plan")
+
+	require.Equal(before.TrackingPlanReport{
+		CheckedEvents: 2,
+		InvalidEvents: 1,
+		Violations:    map[string]int{`Song Played: property "genre" is required`: 1},
+	}, plugin.Report())
+}
+
+func (t *TrackingPlanPluginSuite) TestAnnotate() {
+	plugin, results := t.setupPlugin(types.TrackingPlanModeAnnotate, &types.Plan{Branch: "main", Version: "2"})
+
+	eventProperties := map[string]interface{}{"genre": 1}
+	event := &types.Event{EventType: "Song Played", EventProperties: eventProperties}
+
+	require := t.Require()
+	require.Same(event, plugin.Execute(event))
+	require.Empty(*results)
+	require.Nil(event.Plan, "Config.Plan takes precedence")
+	require.Equal([]string{`Song Played: property "genre" must be of type string`},
+		event.EventProperties["tracking_plan_violations"])
+	require.Equal(map[string]interface{}{"genre": 1}, eventProperties, "properties of the caller are not changed")
+
+	event = &types.Event{EventType: "Song Played"}
+	require.Same(event, plugin.Execute(event))
+	require.Equal(map[string]interface{}{
+		"tracking_plan_violations": []string{`Song Played: property "genre" is required`},
+	}, event.EventProperties)
+}
+
+func (t *TrackingPlanPluginSuite) TestBlock() {
+	plugin, results := t.setupPlugin(types.TrackingPlanModeBlock, nil)
+
+	event := &types.Event{EventType: "Song Skipped"}
+
+	require := t.Require()
+	require.Nil(plugin.Execute(event))
+	require.Equal([]types.ExecuteResult{{
+		PluginName: "tracking-plan",
+		Event:      event,
+		Code:       constants.TrackingPlanViolationCode,
+		Message:    "Tracking plan violation: Song Skipped: is not planned",
+	}}, *results)
+
+	require.Equal(before.TrackingPlanReport{
+		CheckedEvents: 1,
+		InvalidEvents: 1,
+		BlockedEvents: 1,
+		Violations:    map[string]int{"Song Skipped: is not planned": 1},
+	}, plugin.Report())
+}
+
+func (t *TrackingPlanPluginSuite) setupPlugin(
+	mode types.TrackingPlanMode, plan *types.Plan,
+) (before.TrackingPlanPlugin, *[]types.ExecuteResult) {
+	plugin := before.NewTrackingPlanPlugin(before.TrackingPlanPluginOptions{
+		Plan: trackingPlan,
+		Mode: mode,
+	})
+
+	var results []types.ExecuteResult
+
+	plugin.Setup(types.Config{
+		Plan:   plan,
+		Logger: loggers.NewDefaultLogger(),
+		ExecuteCallback: func(result types.ExecuteResult) {
+			results = append(results, result)
+		},
+	})
+
+	return plugin, &results
+}
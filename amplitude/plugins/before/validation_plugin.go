@@ -0,0 +1,475 @@
+package before
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// invalidIDs are placeholder IDs the server rejects.
+var invalidIDs = map[string]struct{}{
+	"null": {}, "none": {}, "nil": {}, "undefined": {}, "unknown": {}, "dummy": {},
+	"0": {}, "-1": {}, "00000000-0000-0000-0000-000000000000": {},
+}
+
+// reservedEventTypes are the event types starting with $ that may be tracked.
+var reservedEventTypes = map[string]struct{}{
+	constants.IdentifyEventType:      {},
+	constants.GroupIdentifyEventType: {},
+}
+
+// minEventTime is the earliest accepted event time, earlier times are likely in seconds or unset.
+var minEventTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type ValidationPluginOptions struct {
+	Policy types.ValidationPolicy
+
+	// MaxClockSkew is how far in the future event times are accepted, it defaults to one hour.
+	MaxClockSkew time.Duration
+	Now          func() time.Time
+
+	// StrictChecks applies the policy to events the server accepts but that are likely wrong,
+	// i.e. a time before 2000 or a quantity without price. Otherwise such events are sent with a warning,
+	// and repaired first under ValidationPolicyRepair.
+	StrictChecks bool
+}
+
+// ValidationPlugin is a Before plugin that checks events against the rules of the server,
+// so invalid events are not sent only to be rejected with 400.
+type ValidationPlugin struct {
+	options         ValidationPluginOptions
+	minIDLength     int
+	logger          types.Logger
+	executeCallback func(result types.ExecuteResult)
+}
+
+func NewValidationPlugin(options ValidationPluginOptions) types.BeforePlugin {
+	if options.MaxClockSkew <= 0 {
+		options.MaxClockSkew = time.Hour
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &ValidationPlugin{options: options}
+}
+
+func (p *ValidationPlugin) Name() string {
+	return "validation"
+}
+
+func (p *ValidationPlugin) Type() types.PluginType {
+	return types.PluginTypeBefore
+}
+
+func (p *ValidationPlugin) Setup(config types.Config) {
+	p.minIDLength = config.MinIDLength
+	if p.minIDLength == 0 {
+		p.minIDLength = constants.DefaultMinIDLength
+	}
+
+	p.logger = config.Logger
+	p.executeCallback = config.ExecuteCallback
+}
+
+// Execute validates the event and applies the policy if it's invalid.
+// It returns nil if the event is rejected.
+func (p *ValidationPlugin) Execute(event *types.Event) *types.Event {
+	issues := p.validate(event)
+
+	var warnings validationIssues
+	if !p.options.StrictChecks {
+		issues, warnings = issues.split()
+	}
+
+	switch {
+	case len(issues) == 0:
+	case p.options.Policy == types.ValidationPolicyPassThrough:
+		p.logger.Warnf("Invalid event is sent anyway: %s", issues)
+	case p.options.Policy == types.ValidationPolicyRepair && issues.repairable():
+		issues.repair()
+		p.logger.Warnf("Invalid event is repaired: %s", issues)
+	default:
+		p.reject(event, issues)
+
+		return nil
+	}
+
+	p.warn(warnings)
+
+	return event
+}
+
+// warn logs issues the server accepts, under ValidationPolicyRepair they are repaired if they can be.
+func (p *ValidationPlugin) warn(warnings validationIssues) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	if p.options.Policy == types.ValidationPolicyRepair && warnings.repairable() {
+		warnings.repair()
+		p.logger.Warnf("Event is repaired: %s", warnings)
+
+		return
+	}
+
+	p.logger.Warnf("Event is likely invalid: %s", warnings)
+}
+
+func (p *ValidationPlugin) reject(event *types.Event, issues validationIssues) {
+	p.logger.Errorf("Invalid event is rejected: %s", issues)
+
+	if p.executeCallback == nil {
+		return
+	}
+
+	p.executeCallback(types.ExecuteResult{
+		PluginName: p.Name(),
+		Event:      event,
+		Code:       constants.InvalidEventCode,
+		Message:    "Invalid event: " + issues.String(),
+	})
+}
+
+// validationIssue is a problem of an event, repair is nil if it can't be fixed.
+// A warning is a problem the server accepts, it only applies the policy with StrictChecks.
+type validationIssue struct {
+	message string
+	repair  func()
+	warning bool
+}
+
+type validationIssues []validationIssue
+
+func (issues validationIssues) repairable() bool {
+	for _, issue := range issues {
+		if issue.repair == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (issues validationIssues) repair() {
+	for _, issue := range issues {
+		issue.repair()
+	}
+}
+
+// split returns the issues that aren't warnings and the warnings.
+func (issues validationIssues) split() (validationIssues, validationIssues) {
+	var errors, warnings validationIssues
+
+	for _, issue := range issues {
+		if issue.warning {
+			warnings = append(warnings, issue)
+		} else {
+			errors = append(errors, issue)
+		}
+	}
+
+	return errors, warnings
+}
+
+func (issues validationIssues) String() string {
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (p *ValidationPlugin) validate(event *types.Event) validationIssues {
+	var issues validationIssues
+
+	issues = append(issues, p.validateEventType(event)...)
+	issues = append(issues, p.validateIDs(event)...)
+	issues = append(issues, p.validateTime(event)...)
+	issues = append(issues, p.validateLocation(event)...)
+	issues = append(issues, p.validateRevenue(event)...)
+	issues = append(issues, p.validateProperties("event_properties", event.EventProperties, func(key string) {
+		event.EventProperties = withoutProperty(event.EventProperties, key)
+	})...)
+
+	for _, op := range sortedIdentityOps(event.UserProperties) {
+		op := op
+		issues = append(issues, p.validateProperties("user_properties."+string(op), event.UserProperties[op], func(key string) {
+			event.UserProperties = withoutOperationProperty(event.UserProperties, op, key)
+		})...)
+	}
+
+	for _, op := range sortedIdentityOps(event.GroupProperties) {
+		op := op
+		issues = append(issues, p.validateProperties("group_properties."+string(op), event.GroupProperties[op], func(key string) {
+			event.GroupProperties = withoutOperationProperty(event.GroupProperties, op, key)
+		})...)
+	}
+
+	return issues
+}
+
+func (p *ValidationPlugin) validateEventType(event *types.Event) validationIssues {
+	if event.EventType == "" {
+		return validationIssues{{message: "event_type is empty"}}
+	}
+
+	if _, ok := reservedEventTypes[event.EventType]; !ok && strings.HasPrefix(event.EventType, "$") {
+		return validationIssues{{message: fmt.Sprintf("event_type %q is reserved", event.EventType)}}
+	}
+
+	return nil
+}
+
+// validateIDs checks user and device IDs, an invalid ID is repaired by clearing it if the other ID is valid.
+func (p *ValidationPlugin) validateIDs(event *types.Event) validationIssues {
+	userID, deviceID := eventIDs(event)
+
+	userIDProblem := p.idProblem(userID)
+	deviceIDProblem := p.idProblem(deviceID)
+
+	switch {
+	case userID == "" && deviceID == "":
+		return validationIssues{{message: "user_id and device_id are empty"}}
+	case userIDProblem != "" && (deviceID == "" || deviceIDProblem != ""):
+		return validationIssues{{message: "user_id " + userIDProblem}}
+	case deviceIDProblem != "" && (userID == "" || userIDProblem != ""):
+		return validationIssues{{message: "device_id " + deviceIDProblem}}
+	case userIDProblem != "":
+		return validationIssues{{
+			message: "user_id " + userIDProblem,
+			repair: func() {
+				event.EventOptions.UserID = ""
+				event.UserID = ""
+			},
+		}}
+	case deviceIDProblem != "":
+		return validationIssues{{
+			message: "device_id " + deviceIDProblem,
+			repair: func() {
+				event.EventOptions.DeviceID = ""
+				event.DeviceID = ""
+			},
+		}}
+	}
+
+	return nil
+}
+
+// idProblem describes why a non-empty ID is invalid, or returns an empty string.
+func (p *ValidationPlugin) idProblem(id string) string {
+	if id == "" {
+		return ""
+	}
+
+	if len(id) < p.minIDLength {
+		return fmt.Sprintf("%q is shorter than %d characters", id, p.minIDLength)
+	}
+
+	if _, ok := invalidIDs[strings.ToLower(id)]; ok {
+		return fmt.Sprintf("%q is a placeholder", id)
+	}
+
+	for _, r := range id {
+		if unicode.IsControl(r) {
+			return fmt.Sprintf("%q has control characters", id)
+		}
+	}
+
+	if strings.TrimSpace(id) != id {
+		return fmt.Sprintf("%q has leading or trailing whitespace", id)
+	}
+
+	return ""
+}
+
+func (p *ValidationPlugin) validateTime(event *types.Event) validationIssues {
+	if event.Time == 0 {
+		return nil
+	}
+
+	eventTime := time.UnixMilli(event.Time)
+	now := p.options.Now()
+
+	switch {
+	case eventTime.Before(minEventTime) && time.Unix(event.Time, 0).After(minEventTime):
+		return validationIssues{{
+			message: fmt.Sprintf("time %d is in seconds instead of milliseconds", event.Time),
+			repair:  func() { event.Time *= 1000 },
+			warning: true,
+		}}
+	case eventTime.Before(minEventTime):
+		return validationIssues{{
+			message: fmt.Sprintf("time %d is before %s", event.Time, minEventTime.Format(time.RFC3339)),
+			repair:  func() { event.Time = now.UnixMilli() },
+			warning: true,
+		}}
+	case eventTime.After(now.Add(p.options.MaxClockSkew)):
+		return validationIssues{{
+			message: fmt.Sprintf("time %d is in the future", event.Time),
+			repair:  func() { event.Time = now.UnixMilli() },
+		}}
+	}
+
+	return nil
+}
+
+func (p *ValidationPlugin) validateLocation(event *types.Event) validationIssues {
+	if isValidFloat(event.LocationLat) && math.Abs(event.LocationLat) <= 90 &&
+		isValidFloat(event.LocationLng) && math.Abs(event.LocationLng) <= 180 {
+		return nil
+	}
+
+	return validationIssues{{
+		message: fmt.Sprintf("location %v, %v is out of range", event.LocationLat, event.LocationLng),
+		repair: func() {
+			event.LocationLat = 0
+			event.LocationLng = 0
+		},
+	}}
+}
+
+func (p *ValidationPlugin) validateRevenue(event *types.Event) validationIssues {
+	var issues validationIssues
+
+	if !isValidFloat(event.Price) {
+		issues = append(issues, validationIssue{message: "price is not a number"})
+	}
+
+	if !isValidFloat(event.Revenue) {
+		issues = append(issues, validationIssue{message: "revenue is not a number"})
+	}
+
+	if event.Quantity < 0 {
+		issues = append(issues, validationIssue{message: fmt.Sprintf("quantity %d is negative", event.Quantity)})
+	}
+
+	if event.Quantity != 0 && event.Price == 0 {
+		issues = append(issues, validationIssue{message: "quantity is set without price", warning: true})
+	}
+
+	if event.Currency != "" && !isCurrencyCode(event.Currency) {
+		currency := strings.ToUpper(strings.TrimSpace(event.Currency))
+
+		issue := validationIssue{message: fmt.Sprintf("currency %q is not an ISO 4217 code", event.Currency)}
+		if isCurrencyCode(currency) {
+			issue.repair = func() { event.Currency = currency }
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// validateProperties checks that property values can be encoded as JSON, a property that can't is repaired by dropping it
+// with remove.
+func (p *ValidationPlugin) validateProperties(
+	path string, properties map[string]interface{}, remove func(key string),
+) validationIssues {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var issues validationIssues
+
+	for _, key := range keys {
+		key := key
+
+		if _, err := json.Marshal(properties[key]); err != nil {
+			issues = append(issues, validationIssue{
+				message: fmt.Sprintf("%s.%s can't be encoded: %s", path, key, err),
+				repair:  func() { remove(key) },
+			})
+		}
+	}
+
+	return issues
+}
+
+// withoutProperty returns a copy of properties without the key.
+// The maps of the event may be shared with the caller, so they are replaced instead of changed.
+func withoutProperty(properties map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(properties))
+
+	for property, value := range properties {
+		if property != key {
+			result[property] = value
+		}
+	}
+
+	return result
+}
+
+// withoutOperationProperty returns a copy of properties without the key of the operation.
+func withoutOperationProperty(
+	properties map[types.IdentityOp]map[string]interface{}, op types.IdentityOp, key string,
+) map[types.IdentityOp]map[string]interface{} {
+	result := make(map[types.IdentityOp]map[string]interface{}, len(properties))
+
+	for operation, values := range properties {
+		result[operation] = values
+	}
+
+	result[op] = withoutProperty(properties[op], key)
+
+	return result
+}
+
+// eventIDs returns the user and device IDs of the event, EventOptions taking precedence.
+func eventIDs(event *types.Event) (string, string) {
+	userID := event.EventOptions.UserID
+	if userID == "" {
+		userID = event.UserID
+	}
+
+	deviceID := event.EventOptions.DeviceID
+	if deviceID == "" {
+		deviceID = event.DeviceID
+	}
+
+	return userID, deviceID
+}
+
+func sortedIdentityOps(properties map[types.IdentityOp]map[string]interface{}) []types.IdentityOp {
+	ops := make([]types.IdentityOp, 0, len(properties))
+	for op := range properties {
+		ops = append(ops, op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i] < ops[j]
+	})
+
+	return ops
+}
+
+func isValidFloat(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func isCurrencyCode(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
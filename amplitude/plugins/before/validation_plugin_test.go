@@ -0,0 +1,240 @@
+package before_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/loggers"
+	"github.com/amplitude/analytics-go/amplitude/plugins/before"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestValidationPlugin(t *testing.T) {
+	suite.Run(t, new(ValidationPluginSuite))
+}
+
+type ValidationPluginSuite struct {
+	suite.Suite
+}
+
+var validationNow = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func (t *ValidationPluginSuite) TestValidEvent() {
+	plugin, results := t.setupPlugin(types.ValidationPolicyReject)
+
+	event := t.createEvent()
+	event.UserProperties = map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro"},
+	}
+
+	require := t.Require()
+	require.Same(event, plugin.Execute(event))
+	require.Empty(*results)
+
+	identify := &types.Event{EventType: constants.IdentifyEventType, EventOptions: types.EventOptions{DeviceID: "device-1"}}
+	require.Same(identify, plugin.Execute(identify))
+}
+
+func (t *ValidationPluginSuite) TestReject() {
+	tests := []struct {
+		name    string
+		modify  func(event *types.Event)
+		message string
+	}{
+		{
+			name:    "empty event type",
+			modify:  func(event *types.Event) { event.EventType = "" },
+			message: "Invalid event: event_type is empty",
+		},
+		{
+			name:    "reserved event type",
+			modify:  func(event *types.Event) { event.EventType = "$purchase" },
+			message: `Invalid event: event_type "$purchase" is reserved`,
+		},
+		{
+			name:    "no IDs",
+			modify:  func(event *types.Event) { event.EventOptions.UserID = "" },
+			message: "Invalid event: user_id and device_id are empty",
+		},
+		{
+			name:    "short user ID",
+			modify:  func(event *types.Event) { event.EventOptions.UserID = "u1" },
+			message: `Invalid event: user_id "u1" is shorter than 5 characters`,
+		},
+		{
+			name:    "placeholder user ID",
+			modify:  func(event *types.Event) { event.EventOptions.UserID = "undefined" },
+			message: `Invalid event: user_id "undefined" is a placeholder`,
+		},
+		{
+			name:    "time in the future",
+			modify:  func(event *types.Event) { event.SetTime(validationNow.Add(time.Hour * 2)) },
+			message: "Invalid event: time 1685628000000 is in the future",
+		},
+		{
+			name: "revenue",
+			modify: func(event *types.Event) {
+				event.Quantity = 2
+				event.Revenue = math.NaN()
+			},
+			message: "Invalid event: revenue is not a number",
+		},
+		{
+			name:    "property",
+			modify:  func(event *types.Event) { event.EventProperties["callback"] = func() {} },
+			message: "Invalid event: event_properties.callback can't be encoded: json: unsupported type: func()",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func() {
+			plugin, results := t.setupPlugin(types.ValidationPolicyReject)
+
+			event := t.createEvent()
+			tt.modify(event)
+
+			require := t.Require()
+			require.Nil(plugin.Execute(event))
+			require.Equal([]types.ExecuteResult{{
+				PluginName: "validation",
+				Event:      event,
+				Code:       constants.InvalidEventCode,
+				Message:    tt.message,
+			}}, *results)
+		})
+	}
+}
+
+func (t *ValidationPluginSuite) TestWarnings() {
+	plugin, results := t.setupPlugin(types.ValidationPolicyReject)
+
+	backfilled := t.createEvent()
+	backfilled.SetTime(time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC))
+	backfilled.Quantity = 2
+
+	require := t.Require()
+	require.Same(backfilled, plugin.Execute(backfilled))
+	require.Empty(*results)
+	require.Equal(int64(946598400000), backfilled.Time)
+
+	strictPlugin := before.NewValidationPlugin(before.ValidationPluginOptions{
+		Policy:       types.ValidationPolicyReject,
+		Now:          func() time.Time { return validationNow },
+		StrictChecks: true,
+	})
+	strictPlugin.Setup(types.Config{
+		Logger: loggers.NewDefaultLogger(),
+		ExecuteCallback: func(result types.ExecuteResult) {
+			*results = append(*results, result)
+		},
+	})
+
+	require.Nil(strictPlugin.Execute(backfilled))
+	require.Len(*results, 1)
+	require.Equal("Invalid event: time 946598400000 is in seconds instead of milliseconds; quantity is set without price",
+		(*results)[0].Message)
+}
+
+func (t *ValidationPluginSuite) TestRepair() {
+	plugin, results := t.setupPlugin(types.ValidationPolicyRepair)
+
+	event := t.createEvent()
+	event.EventOptions.DeviceID = " device-1"
+	event.Time = validationNow.Unix()
+	event.LocationLat = 91
+	event.Currency = "usd"
+	event.EventProperties["channel"] = make(chan int)
+
+	require := t.Require()
+	require.Same(event, plugin.Execute(event))
+	require.Empty(*results)
+
+	require.Empty(event.EventOptions.DeviceID)
+	require.Equal("user-1", event.EventOptions.UserID)
+	require.Equal(validationNow.UnixMilli(), event.Time)
+	require.Zero(event.LocationLat)
+	require.Equal("USD", event.Currency)
+	require.Equal(map[string]interface{}{"count": 1}, event.EventProperties)
+
+	// An event type can't be repaired.
+	event = t.createEvent()
+	event.EventType = "$custom"
+	event.LocationLat = 91
+
+	require.Nil(plugin.Execute(event))
+	require.Len(*results, 1)
+	require.Equal(float64(91), event.LocationLat)
+}
+
+func (t *ValidationPluginSuite) TestRepair_CallerMapsUnchanged() {
+	plugin, _ := t.setupPlugin(types.ValidationPolicyRepair)
+
+	eventProperties := map[string]interface{}{"count": 1, "channel": make(chan int), "func": func() {}}
+	setProperties := map[string]interface{}{"plan": "pro", "channel": make(chan int)}
+	userProperties := map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet:   setProperties,
+		types.IdentityOpUnset: {"old": "-"},
+	}
+
+	event := t.createEvent()
+	event.EventProperties = eventProperties
+	event.UserProperties = userProperties
+
+	require := t.Require()
+	require.Same(event, plugin.Execute(event))
+
+	require.Equal(map[string]interface{}{"count": 1}, event.EventProperties)
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet:   {"plan": "pro"},
+		types.IdentityOpUnset: {"old": "-"},
+	}, event.UserProperties)
+
+	require.Len(eventProperties, 3)
+	require.Len(setProperties, 2)
+	require.Len(userProperties[types.IdentityOpSet], 2)
+}
+
+func (t *ValidationPluginSuite) TestPassThrough() {
+	plugin, results := t.setupPlugin(types.ValidationPolicyPassThrough)
+
+	event := t.createEvent()
+	event.EventType = "$custom"
+
+	require := t.Require()
+	require.Same(event, plugin.Execute(event))
+	require.Empty(*results)
+	require.Equal("$custom", event.EventType)
+}
+
+func (t *ValidationPluginSuite) setupPlugin(policy types.ValidationPolicy) (types.BeforePlugin, *[]types.ExecuteResult) {
+	plugin := before.NewValidationPlugin(before.ValidationPluginOptions{
+		Policy: policy,
+		Now:    func() time.Time { return validationNow },
+	})
+
+	var results []types.ExecuteResult
+
+	plugin.Setup(types.Config{
+		Logger: loggers.NewDefaultLogger(),
+		ExecuteCallback: func(result types.ExecuteResult) {
+			results = append(results, result)
+		},
+	})
+
+	return plugin, &results
+}
+
+func (t *ValidationPluginSuite) createEvent() *types.Event {
+	return &types.Event{
+		EventType: "event-A",
+		EventOptions: types.EventOptions{
+			UserID: "user-1",
+			Time:   validationNow.Add(-time.Minute).UnixMilli(),
+		},
+		EventProperties: map[string]interface{}{"count": 1},
+	}
+}
@@ -1,12 +1,18 @@
 package destination
 
 import (
+	"context"
+	"hash/fnv"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/metrics"
 	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
-
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
@@ -14,26 +20,36 @@ func NewAmplitudePlugin() types.ExtendedDestinationPlugin {
 	return &amplitudePlugin{}
 }
 
+// endOfTime counts every stored event, including events waiting for a retry.
+var endOfTime = time.Unix(1<<62, 0)
+
 type amplitudePlugin struct {
 	config            types.Config
+	metrics           types.Metrics
 	storage           types.EventStorage
 	client            internal.AmplitudeHTTPClient
 	responseProcessor internal.AmplitudeResponseProcessor
-	messageChannel    chan amplitudeMessage
+	circuitBreaker    internal.CircuitBreaker
+	eventOrderKeeper  internal.EventOrderKeeper
+	messageChannel    chan *types.Event
 	messageChannelMu  sync.RWMutex
+	flushChannel      chan *sync.WaitGroup
+	done              chan struct{}
 	callbackWg        sync.WaitGroup
+	resultObserver    func(result types.ExecuteResult)
 
-	chunkSize   int
-	sizeDivider int
-}
+	chunkSize          int
+	sizeDivider        int
+	successfulRequests int
+	chunkSizeMu        sync.Mutex
 
-type amplitudeMessage struct {
-	event *types.Event
-	wg    *sync.WaitGroup
+	// batchUntil is when AutoBatch stops sending to the batch endpoint after the daily quota was exceeded.
+	batchUntil time.Time
+	routingMu  sync.Mutex
 }
 
 func (p *amplitudePlugin) Name() string {
-	return "amplitude"
+	return constants.AmplitudePluginName
 }
 
 func (p *amplitudePlugin) Type() types.PluginType {
@@ -43,26 +59,49 @@ func (p *amplitudePlugin) Type() types.PluginType {
 func (p *amplitudePlugin) Setup(config types.Config) {
 	p.config = config
 
+	p.metrics = config.Metrics
+	if p.metrics == nil {
+		p.metrics = metrics.NewNoopMetrics()
+	}
+
 	p.sizeDivider = config.FlushSizeDivider
 	if p.sizeDivider < 1 {
 		p.sizeDivider = 1
 	}
 
-	p.chunkSize = config.FlushQueueSize / p.sizeDivider
-	if p.chunkSize < 1 {
-		p.chunkSize = 1
-	}
+	p.updateChunkSize()
 
 	p.storage = config.StorageFactory()
-	p.messageChannel = make(chan amplitudeMessage, config.MaxStorageCapacity)
+	p.messageChannel = make(chan *types.Event, config.MaxStorageCapacity)
+	p.flushChannel = make(chan *sync.WaitGroup)
+	p.done = make(chan struct{})
+
+	defaultEndpoint := types.EndpointHTTPAPI
+	if config.UseBatch {
+		defaultEndpoint = types.EndpointBatch
+	}
+
+	if p.config.BatchServerURL == "" && (config.ServerURL == "" || config.ServerURL == constants.ServerURLs[config.ServerZone]) {
+		p.config.BatchServerURL = constants.ServerBatchURLs[config.ServerZone]
+	}
 
 	if p.client == nil {
-		p.client = internal.NewAmplitudeHTTPClient(
-			config.ServerURL,
-			internal.AmplitudePayloadOptions{MinIDLength: config.MinIDLength},
-			config.Logger,
-			config.ConnectionTimeout,
-		)
+		p.client = internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+			ServerURL:         config.ServerURL,
+			PayloadOptions:    internal.AmplitudePayloadOptions{MinIDLength: config.MinIDLength},
+			Logger:            config.Logger,
+			ConnectionTimeout: config.ConnectionTimeout,
+			Metrics:           p.metrics,
+			TracerProvider:    config.TracerProvider,
+			Compressor:        config.Compressor,
+			HTTPClient:        config.HTTPClient,
+			Transport:         config.HTTPTransport,
+			Headers:           config.HTTPHeaders,
+
+			BatchServerURL:       p.config.BatchServerURL,
+			MaxPayloadBytes:      constants.EndpointLimits[defaultEndpoint].MaxPayloadBytes,
+			BatchMaxPayloadBytes: constants.EndpointLimits[types.EndpointBatch].MaxPayloadBytes,
+		})
 	}
 
 	if p.responseProcessor == nil {
@@ -70,15 +109,40 @@ func (p *amplitudePlugin) Setup(config types.Config) {
 			MaxRetries:             config.FlushMaxRetries,
 			RetryBaseInterval:      config.RetryBaseInterval,
 			RetryThrottledInterval: config.RetryThrottledInterval,
+			RetryMaxInterval:       config.RetryMaxInterval,
+			BackoffStrategy:        config.RetryBackoffStrategy,
 			Now:                    time.Now,
 			Logger:                 config.Logger,
+			Metrics:                p.metrics,
+
+			RetryExceededDailyQuota: config.AutoBatch && !config.UseBatch,
+			SalvageLimits:           p.truncationLimits().Half(),
 		})
 	}
 
-	go p.start(p.messageChannel)
+	if config.CircuitBreakerThreshold > 0 {
+		openTimeout := config.CircuitBreakerOpenTimeout
+		if openTimeout <= 0 {
+			openTimeout = constants.DefaultConfig.CircuitBreakerOpenTimeout
+		}
+
+		p.circuitBreaker = internal.NewCircuitBreaker(internal.CircuitBreakerOptions{
+			FailureThreshold: config.CircuitBreakerThreshold,
+			OpenTimeout:      openTimeout,
+			OnStateChange:    p.reportCircuitState,
+		})
+	}
+
+	if config.PreserveUserEventOrder {
+		p.eventOrderKeeper = internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{Now: time.Now})
+	}
+
+	go p.start(p.messageChannel, p.flushChannel)
 }
 
-func (p *amplitudePlugin) start(messageChannel <-chan amplitudeMessage) {
+func (p *amplitudePlugin) start(messageChannel <-chan *types.Event, flushChannel <-chan *sync.WaitGroup) {
+	defer close(p.done)
+
 	defer func() {
 		if r := recover(); r != nil {
 			p.config.Logger.Errorf("Panic in AmplitudePlugin: %s", r)
@@ -99,22 +163,88 @@ func (p *amplitudePlugin) start(messageChannel <-chan amplitudeMessage) {
 		select {
 		case <-autoFlushTicker.C:
 			p.sendEventsFromStorage(nil)
-		case message, ok := <-messageChannel:
+		case wg := <-flushChannel:
+			// Events queued before the flush was requested are sent with it.
+			p.drainMessages(messageChannel)
+			p.sendEventsFromStorage(wg)
+			autoFlushTicker.Reset(p.config.FlushInterval)
+		case event, ok := <-messageChannel:
 			if !ok {
 				return
 			}
 
-			if message.wg != nil {
-				p.sendEventsFromStorage(message.wg)
+			p.metrics.SetGauge(metrics.QueueDepth, float64(len(messageChannel)), nil)
+
+			if p.pushEvent(event) {
 				autoFlushTicker.Reset(p.config.FlushInterval)
-			} else {
-				p.storage.PushNew(&types.StorageEvent{Event: message.event})
+			}
+		}
+	}
+}
 
-				if p.storage.Count(time.Now()) >= p.chunkSize {
-					p.sendEventsFromStorage(nil)
-					autoFlushTicker.Reset(p.config.FlushInterval)
-				}
+// pushEvent pushes the event to storage and sends a chunk if enough events are waiting.
+// It returns true if events have been sent.
+func (p *amplitudePlugin) pushEvent(event *types.Event) bool {
+	p.storage.PushNew(p.newStorageEvent(event))
+
+	count := p.storage.Count(time.Now())
+	p.metrics.SetGauge(metrics.StorageDepth, float64(count), nil)
+
+	if count >= p.currentChunkSize() {
+		p.sendEventsFromStorage(nil)
+
+		return true
+	}
+
+	return false
+}
+
+// newStorageEvent wraps the event for storage, truncating it with TruncateEvents.
+func (p *amplitudePlugin) newStorageEvent(event *types.Event) *types.StorageEvent {
+	storageEvent := &types.StorageEvent{Event: event}
+
+	if p.config.TruncateEvents {
+		storageEvent.Truncations = internal.TruncateEvent(event, p.truncationLimits())
+	}
+
+	return storageEvent
+}
+
+// truncationLimits returns the limits of TruncateEvents, they are not enforced if TruncateEvents is false.
+func (p *amplitudePlugin) truncationLimits() internal.TruncationLimits {
+	if !p.config.TruncateEvents {
+		return internal.TruncationLimits{}
+	}
+
+	limits := internal.TruncationLimits{
+		MaxStringLength: constants.MaxStringLength,
+		MaxPropertyKeys: constants.MaxPropertyKeys,
+		MaxArrayLength:  p.config.MaxArrayLength,
+		MaxDepth:        p.config.MaxPropertyDepth,
+	}
+
+	if limits.MaxArrayLength <= 0 {
+		limits.MaxArrayLength = constants.DefaultConfig.MaxArrayLength
+	}
+
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = constants.DefaultConfig.MaxPropertyDepth
+	}
+
+	return limits
+}
+
+func (p *amplitudePlugin) drainMessages(messageChannel <-chan *types.Event) {
+	for {
+		select {
+		case event, ok := <-messageChannel:
+			if !ok {
+				return
 			}
+
+			p.pushEvent(event)
+		default:
+			return
 		}
 	}
 }
@@ -126,40 +256,190 @@ func (p *amplitudePlugin) Execute(event *types.Event) {
 		p.config.Logger.Errorf("Invalid event, EventType and either UserID or DeviceID cannot be empty: \n\t%+v", event)
 	}
 
+	_ = p.enqueue(context.Background(), event)
+}
+
+// ExecuteContext pushes the event to storage waiting to be sent.
+// Unlike Execute, it rejects invalid events and reports events dropped because the queue is full.
+// With QueueFullPolicyBlock it stops waiting for room in the queue when the context is done.
+func (p *amplitudePlugin) ExecuteContext(ctx context.Context, event *types.Event) error {
+	if !IsValidAmplitudeEvent(event) {
+		p.config.Logger.Errorf("Invalid event, EventType and either UserID or DeviceID cannot be empty: \n\t%+v", event)
+
+		return &types.ValidationError{Errors: []string{"EventType and either UserID or DeviceID cannot be empty"}}
+	}
+
+	return p.enqueue(ctx, event)
+}
+
+func (p *amplitudePlugin) enqueue(ctx context.Context, event *types.Event) error {
 	p.messageChannelMu.RLock()
 	defer p.messageChannelMu.RUnlock()
 
+	if p.messageChannel == nil {
+		return types.ErrClientShutdown
+	}
+
 	select {
-	case p.messageChannel <- amplitudeMessage{
-		event: event,
-		wg:    nil,
-	}:
+	case p.messageChannel <- event:
+		p.metrics.SetGauge(metrics.QueueDepth, float64(len(p.messageChannel)), nil)
+
+		return nil
+	default:
+	}
+
+	switch p.config.QueueFullPolicy {
+	case types.QueueFullPolicyDropOldest:
+		p.enqueueDroppingOldest(event)
+
+		return nil
+	case types.QueueFullPolicyBlock:
+		return p.enqueueBlocking(ctx, event)
+	case types.QueueFullPolicySpillToStorage:
+		if p.storage.Count(endOfTime) >= p.config.MaxStorageCapacity {
+			p.reportDroppedEvent(event, constants.DroppedNewestEventCode, "storage_full", "Event dropped, queue and storage are full")
+
+			return types.ErrQueueFull
+		}
+
+		p.storage.PushNew(p.newStorageEvent(event))
+
+		return nil
 	default:
+		p.reportDroppedEvent(event, constants.DroppedNewestEventCode, "queue_full", "Event dropped, queue is full")
+
+		return types.ErrQueueFull
 	}
 }
 
+func (p *amplitudePlugin) enqueueDroppingOldest(event *types.Event) {
+	for {
+		select {
+		case droppedEvent := <-p.messageChannel:
+			p.reportDroppedEvent(
+				droppedEvent, constants.DroppedOldestEventCode, "queue_full_oldest", "Event dropped to make room for a newer event, queue is full",
+			)
+		default:
+		}
+
+		select {
+		case p.messageChannel <- event:
+			return
+		default:
+		}
+	}
+}
+
+func (p *amplitudePlugin) enqueueBlocking(ctx context.Context, event *types.Event) error {
+	blockTimeout := p.config.QueueFullBlockTimeout
+	if blockTimeout <= 0 {
+		blockTimeout = constants.DefaultConfig.QueueFullBlockTimeout
+	}
+
+	timer := time.NewTimer(blockTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.messageChannel <- event:
+		return nil
+	case <-timer.C:
+		p.reportDroppedEvent(event, constants.DroppedBlockTimeoutEventCode, "block_timeout", "Event dropped, queue is still full after waiting")
+
+		return types.ErrQueueFull
+	case <-ctx.Done():
+		p.reportDroppedEvent(
+			event, constants.DroppedBlockTimeoutEventCode, "context_done", "Event dropped, context done while waiting for room in the queue",
+		)
+
+		return ctx.Err()
+	}
+}
+
+func (p *amplitudePlugin) reportDroppedEvent(event *types.Event, code int, reason string, message string) {
+	p.metrics.AddCounter(metrics.EventsDroppedTotal, 1, map[string]string{"reason": reason})
+	p.config.Logger.Warnf("%s: code=%d, event=%+v", message, code, event)
+	p.executeCallback([]*types.StorageEvent{{Event: event}}, code, message, "")
+}
+
+func (p *amplitudePlugin) executeCallback(events []*types.StorageEvent, code int, message string, endpoint types.Endpoint) {
+	executeCallback, resultObserver := p.config.ExecuteCallback, p.resultObserver
+	if (executeCallback == nil && resultObserver == nil) || len(events) == 0 {
+		return
+	}
+
+	var results []types.ExecuteResult
+
+	for _, event := range events {
+		eventMessage := message
+		if len(event.Truncations) > 0 {
+			eventMessage += " Truncated: " + strings.Join(event.Truncations, "; ")
+		}
+
+		for _, callbackEvent := range p.callbackEvents(event) {
+			results = append(results, types.ExecuteResult{
+				PluginName: p.Name(),
+				Event:      callbackEvent,
+				Code:       code,
+				Message:    eventMessage,
+				Endpoint:   endpoint,
+			})
+		}
+	}
+
+	// The result observer doesn't block, so results are only reported in a goroutine for ExecuteCallback.
+	if executeCallback == nil {
+		for _, result := range results {
+			resultObserver(result)
+		}
+
+		return
+	}
+
+	p.callbackWg.Add(1)
+
+	go func() {
+		defer p.callbackWg.Done()
+
+		for _, result := range results {
+			executeCallback(result)
+
+			if resultObserver != nil {
+				resultObserver(result)
+			}
+		}
+	}()
+}
+
+// callbackEvents returns the events reported for a storage event, which are the events merged into it if there are any.
+func (p *amplitudePlugin) callbackEvents(event *types.StorageEvent) []*types.Event {
+	if len(event.CoalescedEvents) > 0 {
+		return event.CoalescedEvents
+	}
+
+	return []*types.Event{event.Event}
+}
+
 func (p *amplitudePlugin) Flush() {
 	p.messageChannelMu.RLock()
-	defer p.messageChannelMu.RUnlock()
+	shutdown := p.messageChannel == nil
+	p.messageChannelMu.RUnlock()
 
-	if p.messageChannel == nil {
+	// The lock is released before waiting, the worker takes it when it stops, and flush returns once it stopped.
+	if shutdown {
 		return
 	}
 
-	p.flush(p.messageChannel)
+	p.flush()
 }
 
-func (p *amplitudePlugin) flush(messageChannel chan<- amplitudeMessage) {
+func (p *amplitudePlugin) flush() {
 	var flushWaitGroup sync.WaitGroup
 
 	flushWaitGroup.Add(1)
 
 	select {
-	case messageChannel <- amplitudeMessage{
-		event: nil,
-		wg:    &flushWaitGroup,
-	}:
-	default:
+	case p.flushChannel <- &flushWaitGroup:
+	case <-p.done:
 		flushWaitGroup.Done()
 	}
 
@@ -171,50 +451,362 @@ func (p *amplitudePlugin) sendEventsFromStorage(wg *sync.WaitGroup) {
 		defer wg.Done()
 	}
 
+	if p.config.FlushWorkers > 1 {
+		p.sendEventsInParallel(p.config.FlushWorkers)
+
+		return
+	}
+
 	for {
-		storageEvents := p.storage.Pull(p.chunkSize, time.Now())
+		storageEvents, lease := p.pullEvents(p.currentChunkSize())
 		if len(storageEvents) == 0 {
 			break
 		}
 
-		events := make([]*types.Event, len(storageEvents))
-		for i, storageEvent := range storageEvents {
-			events[i] = storageEvent.Event
+		p.metrics.ObserveHistogram(metrics.PullSize, float64(len(storageEvents)), nil)
+
+		if !p.sendChunk(lease, storageEvents) {
+			break
 		}
+	}
+}
 
-		response := p.client.Send(internal.AmplitudePayload{
-			APIKey: p.config.APIKey,
-			Events: events,
-		})
+// sendEventsInParallel pulls chunks for all workers at once and sends each partition of them in its own goroutine.
+// It returns once every request is done, so Flush and Shutdown still wait for in-flight requests.
+func (p *amplitudePlugin) sendEventsInParallel(workers int) {
+	for {
+		chunkSize := p.currentChunkSize()
+
+		storageEvents, lease := p.pullEvents(chunkSize * workers)
+		if len(storageEvents) == 0 {
+			break
+		}
+
+		p.metrics.ObserveHistogram(metrics.PullSize, float64(len(storageEvents)), nil)
+
+		var (
+			workersWg sync.WaitGroup
+			stopped   int32
+		)
+
+		for _, partition := range partitionEvents(storageEvents, workers) {
+			if len(partition) == 0 {
+				continue
+			}
 
-		result := p.responseProcessor.Process(storageEvents, response)
+			workersWg.Add(1)
 
-		if result.Code == http.StatusRequestEntityTooLarge && len(result.EventsForRetry) > 0 {
-			p.reduceChunkSize()
+			go func(partition []*types.StorageEvent) {
+				defer workersWg.Done()
+
+				for len(partition) > 0 {
+					size := chunkSize
+					if size > len(partition) {
+						size = len(partition)
+					}
+
+					if !p.sendChunk(lease, partition[:size]) {
+						p.returnEvents(lease, partition[size:])
+						atomic.StoreInt32(&stopped, 1)
+
+						return
+					}
+
+					partition = partition[size:]
+				}
+			}(partition)
 		}
 
-		if len(result.EventsForRetry) > 0 {
-			p.storage.ReturnBack(result.EventsForRetry...)
+		workersWg.Wait()
+
+		if atomic.LoadInt32(&stopped) == 1 {
+			break
 		}
+	}
+}
 
-		executeCallback := p.config.ExecuteCallback
-		if executeCallback != nil && len(result.EventsForCallback) > 0 {
-			p.callbackWg.Add(1)
-			go func() {
-				defer p.callbackWg.Done()
-				for _, event := range result.EventsForCallback {
-					executeCallback(types.ExecuteResult{
-						PluginName: p.Name(),
-						Event:      event.Event,
-						Code:       result.Code,
-						Message:    result.Message,
-					})
-				}
-			}()
+// sendChunk sends the events in requests within the limits of the selected endpoint, one by one.
+// With PreserveUserEventOrder, events of users with pending retries are held back to storage first.
+// It returns false and returns unsent events back to storage if the circuit breaker doesn't allow sending,
+// or if every event is held back, so held events aren't pulled again in the same flush.
+func (p *amplitudePlugin) sendChunk(lease *types.EventLease, storageEvents []*types.StorageEvent) bool {
+	if p.eventOrderKeeper != nil {
+		var heldEvents []*types.StorageEvent
+
+		storageEvents, heldEvents = p.eventOrderKeeper.Hold(storageEvents)
+		if len(heldEvents) > 0 {
+			p.metrics.AddCounter(metrics.EventsHeldTotal, float64(len(heldEvents)), nil)
+			p.returnEvents(lease, heldEvents)
+		}
+
+		if len(storageEvents) == 0 {
+			return false
+		}
+	}
+
+	// Leased events are acknowledged as they were pulled, so they can't be replaced by merged events.
+	if p.config.CoalesceIdentifies && lease == nil {
+		var (
+			coalesced int
+			pending   func(*types.StorageEvent) bool
+		)
+
+		// Pending events are tracked by pointer by the order keeper, so they are never replaced.
+		if p.eventOrderKeeper != nil {
+			pending = p.eventOrderKeeper.Pending
+		}
+
+		storageEvents, coalesced = internal.CoalesceIdentifies(storageEvents, pending)
+		if coalesced > 0 {
+			p.metrics.AddCounter(metrics.IdentifiesCoalescedTotal, float64(coalesced), nil)
+		}
+	}
+
+	endpoint := p.selectEndpoint(len(storageEvents))
+	sentCount := 0
+
+	for _, requestEvents := range p.splitRequests(storageEvents, endpoint) {
+		if p.circuitBreaker != nil && !p.circuitBreaker.Allow() {
+			p.returnEvents(lease, storageEvents[sentCount:])
+
+			return false
+		}
+
+		p.sendRequest(lease, requestEvents, endpoint)
+		sentCount += len(requestEvents)
+	}
+
+	return true
+}
+
+// splitRequests splits events into requests within the event count and payload bytes limits.
+// An event over the payload bytes limit is sent alone.
+func (p *amplitudePlugin) splitRequests(events []*types.StorageEvent, endpoint types.Endpoint) [][]*types.StorageEvent {
+	limits := constants.EndpointLimits[endpoint]
+
+	maxPayloadBytes := limits.MaxPayloadBytes
+	if p.config.FlushMaxPayloadBytes > 0 && p.config.FlushMaxPayloadBytes < maxPayloadBytes {
+		maxPayloadBytes = p.config.FlushMaxPayloadBytes
+	}
+
+	envelopeSize := internal.PayloadEnvelopeSize(p.config.APIKey)
+
+	var requests [][]*types.StorageEvent
+
+	start, payloadSize := 0, envelopeSize
+
+	for i, event := range events {
+		eventSize := internal.EventSize(event.Event)
+
+		if i > start && (i-start >= limits.MaxEvents || payloadSize+eventSize > maxPayloadBytes) {
+			requests = append(requests, events[start:i])
+			start, payloadSize = i, envelopeSize
+		}
+
+		payloadSize += eventSize
+	}
+
+	if start < len(events) {
+		requests = append(requests, events[start:])
+	}
+
+	return requests
+}
+
+// sendRequest sends the events to the endpoint and completes them with the response.
+func (p *amplitudePlugin) sendRequest(lease *types.EventLease, storageEvents []*types.StorageEvent, endpoint types.Endpoint) {
+	events := make([]*types.Event, len(storageEvents))
+	retryCount := 0
+
+	for i, storageEvent := range storageEvents {
+		events[i] = storageEvent.Event
+
+		if storageEvent.RetryCount > retryCount {
+			retryCount = storageEvent.RetryCount
+		}
+	}
+
+	payload := internal.AmplitudePayload{
+		APIKey:     p.config.APIKey,
+		Events:     events,
+		RetryCount: retryCount,
+	}
+
+	// With UseBatch ServerURL is the batch endpoint already.
+	if endpoint == types.EndpointBatch && !p.config.UseBatch {
+		payload.Endpoint = types.EndpointBatch
+	}
+
+	response := p.client.Send(payload)
+
+	if p.circuitBreaker != nil {
+		if response.IsServerFailure() {
+			p.circuitBreaker.RecordFailure()
+		} else {
+			p.circuitBreaker.RecordSuccess()
+		}
+	}
+
+	if endpoint != types.EndpointBatch && response.HasExceededDailyQuota() {
+		p.routeToBatchEndpoint()
+	}
+
+	result := p.responseProcessor.Process(storageEvents, response)
+
+	if p.eventOrderKeeper != nil {
+		p.eventOrderKeeper.Complete(storageEvents, result)
+	}
+
+	if result.Code == http.StatusRequestEntityTooLarge && len(result.EventsForRetry) > 1 {
+		p.reduceChunkSize()
+	} else if response.IsSuccess() {
+		p.recoverChunkSize()
+	}
+
+	p.completeEvents(lease, result)
+	p.executeCallback(result.EventsForCallback, result.Code, result.Message, endpoint)
+}
+
+// selectEndpoint returns the endpoint of the next request.
+// With AutoBatch it's the batch endpoint while a backlog builds up or after the daily quota was exceeded.
+func (p *amplitudePlugin) selectEndpoint(pulledCount int) types.Endpoint {
+	if p.config.UseBatch {
+		return types.EndpointBatch
+	}
+
+	// Batch requests would bypass a custom ServerURL without a BatchServerURL.
+	if !p.config.AutoBatch || p.config.BatchServerURL == "" {
+		return types.EndpointHTTPAPI
+	}
+
+	now := time.Now()
+
+	p.routingMu.Lock()
+	batchUntil := p.batchUntil
+	p.routingMu.Unlock()
+
+	if now.Before(batchUntil) {
+		return types.EndpointBatch
+	}
+
+	backlog := p.config.AutoBatchBacklog
+	if backlog <= 0 {
+		backlog = constants.DefaultConfig.AutoBatchBacklog
+	}
+
+	if pulledCount+p.storage.Count(now) >= backlog {
+		return types.EndpointBatch
+	}
+
+	return types.EndpointHTTPAPI
+}
+
+func (p *amplitudePlugin) routeToBatchEndpoint() {
+	if !p.config.AutoBatch || p.config.UseBatch {
+		return
+	}
+
+	duration := p.config.AutoBatchDuration
+	if duration <= 0 {
+		duration = constants.DefaultConfig.AutoBatchDuration
+	}
+
+	p.routingMu.Lock()
+	p.batchUntil = time.Now().Add(duration)
+	p.routingMu.Unlock()
+
+	p.config.Logger.Infof("Daily quota exceeded, events are sent to the batch endpoint for %s", duration)
+}
+
+// partitionEvents splits events into partitions by user, keeping the order of events in each partition.
+func partitionEvents(events []*types.StorageEvent, partitionCount int) [][]*types.StorageEvent {
+	partitions := make([][]*types.StorageEvent, partitionCount)
+
+	for _, event := range events {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(internal.UserKey(event.Event)))
+		index := hash.Sum32() % uint32(partitionCount)
+
+		partitions[index] = append(partitions[index], event)
+	}
+
+	return partitions
+}
+
+// pullEvents takes up to count events from storage.
+// Events of a LeasingEventStorage are leased, so they stay in storage until completeEvents.
+func (p *amplitudePlugin) pullEvents(count int) ([]*types.StorageEvent, *types.EventLease) {
+	if leasingStorage, ok := p.storage.(types.LeasingEventStorage); ok {
+		leaseTimeout := p.config.StorageLeaseTimeout
+		if leaseTimeout <= 0 {
+			leaseTimeout = constants.DefaultConfig.StorageLeaseTimeout
 		}
+
+		lease := leasingStorage.Lease(count, time.Now(), leaseTimeout)
+
+		return lease.Events, lease
+	}
+
+	return p.storage.Pull(count, time.Now()), nil
+}
+
+// completeEvents returns events for retry back to storage and, for leased events,
+// acknowledges the ones that are done.
+func (p *amplitudePlugin) completeEvents(lease *types.EventLease, result internal.AmplitudeProcessorResult) {
+	if lease != nil {
+		leasingStorage := p.storage.(types.LeasingEventStorage)
+		leasingStorage.Nack(lease, result.EventsForRetry...)
+		leasingStorage.Ack(lease, result.EventsForCallback...)
+
+		return
+	}
+
+	if len(result.EventsForRetry) > 0 {
+		p.storage.ReturnBack(result.EventsForRetry...)
 	}
 }
 
+// returnEvents returns events back to storage as they were pulled.
+func (p *amplitudePlugin) returnEvents(lease *types.EventLease, events []*types.StorageEvent) {
+	if lease != nil {
+		p.storage.(types.LeasingEventStorage).Nack(lease, events...)
+	} else {
+		p.storage.ReturnBack(events...)
+	}
+}
+
+func (p *amplitudePlugin) reportCircuitState(state internal.CircuitState) {
+	p.metrics.SetGauge(metrics.CircuitBreakerState, float64(state), nil)
+
+	switch state {
+	case internal.CircuitOpen:
+		p.config.Logger.Warnf("Circuit breaker is open, sending events is paused")
+	case internal.CircuitHalfOpen:
+		p.config.Logger.Infof("Circuit breaker is half-open, sending a probe request")
+	default:
+		p.config.Logger.Infof("Circuit breaker is closed, sending events is resumed")
+	}
+
+	circuitBreakerCallback := p.config.CircuitBreakerCallback
+	if circuitBreakerCallback == nil {
+		return
+	}
+
+	// The circuit breaker is locked while reporting its state, so the callback is called in a goroutine.
+	p.callbackWg.Add(1)
+
+	go func() {
+		defer p.callbackWg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				p.config.Logger.Errorf("Panic in circuit breaker callback: %s", r)
+			}
+		}()
+
+		circuitBreakerCallback(state)
+	}()
+}
+
 func (p *amplitudePlugin) Shutdown() {
 	p.messageChannelMu.Lock()
 
@@ -229,18 +821,68 @@ func (p *amplitudePlugin) Shutdown() {
 	p.messageChannel = nil
 	p.messageChannelMu.Unlock()
 
-	p.flush(messageChannel)
+	p.flush()
 	close(messageChannel)
 	p.callbackWg.Wait()
+
+	if closer, ok := p.storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			p.config.Logger.Errorf("Closing event storage failed: %s", err)
+		}
+	}
+}
+
+func (p *amplitudePlugin) currentChunkSize() int {
+	p.chunkSizeMu.Lock()
+	defer p.chunkSizeMu.Unlock()
+
+	return p.chunkSize
 }
 
 func (p *amplitudePlugin) reduceChunkSize() {
+	p.chunkSizeMu.Lock()
+	defer p.chunkSizeMu.Unlock()
+
 	p.sizeDivider++
+	p.successfulRequests = 0
+	p.updateChunkSize()
+
+	p.metrics.AddCounter(metrics.ChunkSizeReductionsTotal, 1, nil)
+}
+
+// recoverChunkSize grows a reduced chunk size back one step after FlushSizeRecoverAfter successful requests.
+func (p *amplitudePlugin) recoverChunkSize() {
+	p.chunkSizeMu.Lock()
+	defer p.chunkSizeMu.Unlock()
+
+	if p.sizeDivider <= p.config.FlushSizeDivider || p.sizeDivider <= 1 {
+		return
+	}
+
+	recoverAfter := p.config.FlushSizeRecoverAfter
+	if recoverAfter <= 0 {
+		recoverAfter = constants.DefaultConfig.FlushSizeRecoverAfter
+	}
 
+	p.successfulRequests++
+	if p.successfulRequests < recoverAfter {
+		return
+	}
+
+	p.sizeDivider--
+	p.successfulRequests = 0
+	p.updateChunkSize()
+
+	p.metrics.AddCounter(metrics.ChunkSizeRecoveriesTotal, 1, nil)
+}
+
+func (p *amplitudePlugin) updateChunkSize() {
 	p.chunkSize = p.config.FlushQueueSize / p.sizeDivider
 	if p.chunkSize < 1 {
 		p.chunkSize = 1
 	}
+
+	p.metrics.SetGauge(metrics.ChunkSize, float64(p.chunkSize), nil)
 }
 
 func (p *amplitudePlugin) SetHTTPClient(client internal.AmplitudeHTTPClient) {
@@ -251,6 +893,12 @@ func (p *amplitudePlugin) SetResponseProcessor(responseProcessor internal.Amplit
 	p.responseProcessor = responseProcessor
 }
 
+// SetResultObserver sets a function called with the result of every event after ExecuteCallback.
+// It must be set before Setup and must not block, since it's called while sending events if ExecuteCallback is nil.
+func (p *amplitudePlugin) SetResultObserver(resultObserver func(result types.ExecuteResult)) {
+	p.resultObserver = resultObserver
+}
+
 func IsValidAmplitudeEvent(event *types.Event) bool {
 	userID := event.EventOptions.UserID
 	if userID == "" {
@@ -1,8 +1,14 @@
 package destination_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,11 +17,13 @@ import (
 
 	"github.com/amplitude/analytics-go/amplitude/plugins/destination"
 	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+	"github.com/amplitude/analytics-go/amplitude/storages"
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
 type AmplitudePlugin interface {
 	types.ExtendedDestinationPlugin
+	ExecuteContext(ctx context.Context, event *types.Event) error
 	SetHTTPClient(client internal.AmplitudeHTTPClient)
 	SetResponseProcessor(responseProcessor internal.AmplitudeResponseProcessor)
 }
@@ -377,6 +385,40 @@ func (t *AmplitudePluginSuite) TestAmplitudePlugin_ExplicitShutdown() {
 	storage.AssertExpectations(t.T())
 }
 
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_FlushDuringShutdown() {
+	for i := 0; i < 20; i++ {
+		plugin := destination.NewAmplitudePlugin()
+
+		plugin.Setup(types.Config{
+			APIKey:             "my-api-key",
+			MaxStorageCapacity: 10,
+			FlushInterval:      time.Second * 100,
+			FlushQueueSize:     10,
+			FlushSizeDivider:   1,
+			StorageFactory:     storages.NewInMemoryEventStorage,
+			Logger:             noopLogger{},
+		})
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for j := 0; j < 100; j++ {
+				plugin.Flush()
+			}
+		}()
+
+		plugin.Shutdown()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.FailNow("Flush is blocked by Shutdown")
+		}
+	}
+}
+
 func (t *AmplitudePluginSuite) TestAmplitudePlugin_ReduceChunkSize() {
 	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
 
@@ -455,6 +497,823 @@ func (t *AmplitudePluginSuite) TestAmplitudePlugin_ReduceChunkSize() {
 	plugin.Shutdown()
 }
 
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_LeasingStorage() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	flushInterval := time.Second * 100
+	flushQueueSize := 10
+	leaseTimeout := time.Second * 30
+	event1 := t.createEvent(1)
+	event2 := t.createEvent(2)
+	storageEvent1 := &types.StorageEvent{Event: event1}
+	storageEvent2 := &types.StorageEvent{Event: event2}
+	lease := &types.EventLease{ID: 1, Events: []*types.StorageEvent{storageEvent1, storageEvent2}}
+
+	storage := &mockLeasingStorage{}
+	storage.On("PushNew", storageEvent1).Once()
+	storage.On("Count", mock.Anything).Return(1).Once()
+	storage.On("PushNew", storageEvent2).Once()
+	storage.On("Count", mock.Anything).Return(2).Once()
+	storage.On("Lease", flushQueueSize, mock.Anything, leaseTimeout).Return(lease).Once()
+	storage.On("Lease", flushQueueSize, mock.Anything, leaseTimeout).Return(&types.EventLease{}).Once()
+	storage.On("Nack", lease, []*types.StorageEvent{storageEvent2}).Once()
+	storage.On("Ack", lease, []*types.StorageEvent{storageEvent1}).Once()
+
+	httpClient := &mockHTTPClient{}
+	httpClient.On("Send", internal.AmplitudePayload{
+		APIKey: "my-api-key",
+		Events: []*types.Event{event1, event2},
+	}).Return(internal.AmplitudeResponse{Status: http.StatusTooManyRequests}).Once()
+
+	responseProcessor := &mockResponseProcessor{}
+	responseProcessor.On("Process", []*types.StorageEvent{storageEvent1, storageEvent2}, internal.AmplitudeResponse{Status: http.StatusTooManyRequests}).Return(internal.AmplitudeProcessorResult{
+		Code:              http.StatusTooManyRequests,
+		EventsForCallback: []*types.StorageEvent{storageEvent1},
+		EventsForRetry:    []*types.StorageEvent{storageEvent2},
+	})
+
+	plugin.SetHTTPClient(httpClient)
+	plugin.SetResponseProcessor(responseProcessor)
+
+	plugin.Setup(types.Config{
+		APIKey:              "my-api-key",
+		MaxStorageCapacity:  10,
+		FlushInterval:       flushInterval,
+		FlushQueueSize:      flushQueueSize,
+		FlushSizeDivider:    1,
+		StorageLeaseTimeout: leaseTimeout,
+		StorageFactory: func() types.EventStorage {
+			return storage
+		},
+		Logger: noopLogger{},
+	})
+
+	plugin.Execute(event1)
+	plugin.Execute(event2)
+	plugin.Flush()
+
+	httpClient.AssertExpectations(t.T())
+	responseProcessor.AssertExpectations(t.T())
+	storage.AssertExpectations(t.T())
+
+	storage.On("Lease", flushQueueSize, mock.Anything, leaseTimeout).Return(&types.EventLease{}).Once()
+
+	plugin.Shutdown()
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_ExecuteContext() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	flushQueueSize := 10
+	event1 := t.createEvent(1)
+	event2 := t.createEvent(2)
+	event3 := t.createEvent(3)
+	storageEvent1 := &types.StorageEvent{Event: event1}
+	storageEvent2 := &types.StorageEvent{Event: event2}
+
+	pushStarted := make(chan struct{})
+	unblockPush := make(chan struct{})
+
+	storage := &mockStorage{}
+	storage.On("PushNew", storageEvent1).Run(func(mock.Arguments) {
+		close(pushStarted)
+		<-unblockPush
+	}).Once()
+	storage.On("PushNew", storageEvent2).Once()
+	storage.On("Count", mock.Anything).Return(1)
+	storage.On("Pull", flushQueueSize, mock.Anything).Return(nil)
+
+	plugin.SetHTTPClient(&mockHTTPClient{})
+	plugin.SetResponseProcessor(&mockResponseProcessor{})
+
+	plugin.Setup(types.Config{
+		APIKey:             "my-api-key",
+		MaxStorageCapacity: 1,
+		FlushInterval:      time.Second * 100,
+		FlushQueueSize:     flushQueueSize,
+		FlushSizeDivider:   1,
+		StorageFactory: func() types.EventStorage {
+			return storage
+		},
+		Logger: noopLogger{},
+	})
+
+	require := t.Require()
+
+	require.ErrorIs(plugin.ExecuteContext(context.Background(), &types.Event{EventType: "no-ids"}), types.ErrInvalidEvent)
+
+	require.NoError(plugin.ExecuteContext(context.Background(), event1))
+	<-pushStarted
+	require.NoError(plugin.ExecuteContext(context.Background(), event2))
+	require.ErrorIs(plugin.ExecuteContext(context.Background(), event3), types.ErrQueueFull)
+
+	close(unblockPush)
+	plugin.Shutdown()
+
+	require.ErrorIs(plugin.ExecuteContext(context.Background(), event3), types.ErrClientShutdown)
+	storage.AssertExpectations(t.T())
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_QueueFullPolicy() {
+	event1 := t.createEvent(1)
+	event2 := t.createEvent(2)
+	event3 := t.createEvent(3)
+
+	tests := []struct {
+		name          string
+		policy        types.QueueFullPolicy
+		blockTimeout  time.Duration
+		ctxTimeout    time.Duration
+		expectedErr   error
+		droppedEvent  *types.Event
+		droppedCode   int
+		storageCount  int
+		storageEvents []*types.Event
+	}{
+		{
+			name:          "drop newest",
+			policy:        types.QueueFullPolicyDropNewest,
+			expectedErr:   types.ErrQueueFull,
+			droppedEvent:  event3,
+			droppedCode:   1001,
+			storageEvents: []*types.Event{event1, event2},
+		},
+		{
+			name:          "drop oldest",
+			policy:        types.QueueFullPolicyDropOldest,
+			droppedEvent:  event2,
+			droppedCode:   1002,
+			storageEvents: []*types.Event{event1, event3},
+		},
+		{
+			name:          "block timeout",
+			policy:        types.QueueFullPolicyBlock,
+			blockTimeout:  time.Millisecond * 10,
+			expectedErr:   types.ErrQueueFull,
+			droppedEvent:  event3,
+			droppedCode:   1003,
+			storageEvents: []*types.Event{event1, event2},
+		},
+		{
+			name:          "block context done",
+			policy:        types.QueueFullPolicyBlock,
+			blockTimeout:  time.Minute,
+			ctxTimeout:    time.Millisecond * 10,
+			expectedErr:   context.DeadlineExceeded,
+			droppedEvent:  event3,
+			droppedCode:   1003,
+			storageEvents: []*types.Event{event1, event2},
+		},
+		{
+			name:          "spill to storage",
+			policy:        types.QueueFullPolicySpillToStorage,
+			storageEvents: []*types.Event{event1, event3, event2},
+		},
+		{
+			name:          "spill to full storage",
+			policy:        types.QueueFullPolicySpillToStorage,
+			expectedErr:   types.ErrQueueFull,
+			droppedEvent:  event3,
+			droppedCode:   1001,
+			storageCount:  1,
+			storageEvents: []*types.Event{event1, event2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func() {
+			plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+			pushStarted := make(chan struct{})
+			unblockPush := make(chan struct{})
+
+			storage := &mockStorage{}
+			storage.On("PushNew", &types.StorageEvent{Event: event1}).Run(func(mock.Arguments) {
+				close(pushStarted)
+				<-unblockPush
+			}).Once()
+			for _, event := range tt.storageEvents[1:] {
+				storage.On("PushNew", &types.StorageEvent{Event: event}).Once()
+			}
+			storage.On("Count", mock.Anything).Return(tt.storageCount)
+			storage.On("Pull", 10, mock.Anything).Return(nil)
+
+			plugin.SetHTTPClient(&mockHTTPClient{})
+			plugin.SetResponseProcessor(&mockResponseProcessor{})
+
+			var results []types.ExecuteResult
+			var resultsMu sync.Mutex
+
+			plugin.Setup(types.Config{
+				APIKey:                "my-api-key",
+				MaxStorageCapacity:    1,
+				FlushInterval:         time.Second * 100,
+				FlushQueueSize:        10,
+				FlushSizeDivider:      1,
+				QueueFullPolicy:       tt.policy,
+				QueueFullBlockTimeout: tt.blockTimeout,
+				StorageFactory: func() types.EventStorage {
+					return storage
+				},
+				Logger: noopLogger{},
+				ExecuteCallback: func(result types.ExecuteResult) {
+					resultsMu.Lock()
+					defer resultsMu.Unlock()
+
+					results = append(results, result)
+				},
+			})
+
+			require := t.Require()
+
+			require.NoError(plugin.ExecuteContext(context.Background(), event1))
+			<-pushStarted
+			require.NoError(plugin.ExecuteContext(context.Background(), event2))
+
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+
+			err := plugin.ExecuteContext(ctx, event3)
+			if tt.expectedErr != nil {
+				require.ErrorIs(err, tt.expectedErr)
+			} else {
+				require.NoError(err)
+			}
+
+			close(unblockPush)
+			plugin.Shutdown()
+
+			storage.AssertExpectations(t.T())
+
+			if tt.droppedEvent == nil {
+				require.Empty(results)
+			} else {
+				require.Len(results, 1)
+				require.Equal(tt.droppedEvent, results[0].Event)
+				require.Equal(tt.droppedCode, results[0].Code)
+			}
+		})
+	}
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_CircuitBreaker() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	flushQueueSize := 10
+	event1 := t.createEvent(1)
+	storageEvent1 := &types.StorageEvent{Event: event1}
+	failedResponse := internal.AmplitudeResponse{Err: errors.New("connection refused")}
+
+	storage := &mockStorage{}
+	storage.On("PushNew", storageEvent1).Once()
+	storage.On("Count", mock.Anything).Return(1)
+	storage.On("Pull", flushQueueSize, mock.Anything).Return([]*types.StorageEvent{storageEvent1})
+	storage.On("ReturnBack", []*types.StorageEvent{storageEvent1})
+
+	httpClient := &mockHTTPClient{}
+	httpClient.On("Send", internal.AmplitudePayload{
+		APIKey: "my-api-key",
+		Events: []*types.Event{event1},
+	}).Return(failedResponse).Once()
+
+	responseProcessor := &mockResponseProcessor{}
+	responseProcessor.On("Process", []*types.StorageEvent{storageEvent1}, failedResponse).Return(internal.AmplitudeProcessorResult{
+		EventsForRetry: []*types.StorageEvent{storageEvent1},
+	}).Once()
+
+	plugin.SetHTTPClient(httpClient)
+	plugin.SetResponseProcessor(responseProcessor)
+
+	states := make(chan types.CircuitBreakerState, 1)
+
+	plugin.Setup(types.Config{
+		APIKey:                    "my-api-key",
+		MaxStorageCapacity:        10,
+		FlushInterval:             time.Second * 100,
+		FlushQueueSize:            flushQueueSize,
+		FlushSizeDivider:          1,
+		CircuitBreakerThreshold:   1,
+		CircuitBreakerOpenTimeout: time.Second * 100,
+		StorageFactory: func() types.EventStorage {
+			return storage
+		},
+		Logger: noopLogger{},
+		ExecuteCallback: func(result types.ExecuteResult) {
+			t.NotNil(result.Event)
+		},
+		CircuitBreakerCallback: func(state types.CircuitBreakerState) {
+			states <- state
+		},
+	})
+
+	plugin.Execute(event1)
+	plugin.Flush()
+	plugin.Flush()
+	plugin.Shutdown()
+
+	require := t.Require()
+
+	require.Equal(types.CircuitBreakerOpen, <-states)
+
+	httpClient.AssertExpectations(t.T())
+	httpClient.AssertNumberOfCalls(t.T(), "Send", 1)
+	responseProcessor.AssertExpectations(t.T())
+	storage.AssertExpectations(t.T())
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_FlushWorkers() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{delay: time.Millisecond * 20}
+	plugin.SetHTTPClient(httpClient)
+
+	var callbackCount int32
+
+	plugin.Setup(types.Config{
+		APIKey:             "my-api-key",
+		MaxStorageCapacity: 100,
+		FlushInterval:      time.Second * 100,
+		FlushQueueSize:     5,
+		FlushSizeDivider:   1,
+		FlushMaxRetries:    1,
+		FlushWorkers:       2,
+		StorageFactory:     storages.NewInMemoryEventStorage,
+		Logger:             noopLogger{},
+		ExecuteCallback: func(result types.ExecuteResult) {
+			atomic.AddInt32(&callbackCount, 1)
+		},
+	})
+
+	eventCount := 40
+	for i := 1; i <= eventCount; i++ {
+		event := t.createEvent(i)
+		event.EventOptions.UserID = fmt.Sprintf("user-%d", i%4+1)
+		plugin.Execute(event)
+	}
+
+	plugin.Flush()
+	plugin.Shutdown()
+
+	require := t.Require()
+	require.Equal(int32(eventCount), atomic.LoadInt32(&callbackCount))
+	require.Greater(atomic.LoadInt32(&httpClient.maxInFlight), int32(1))
+
+	lastTimes := make(map[string]int64)
+	sentCount := 0
+
+	for _, payload := range httpClient.payloads {
+		require.LessOrEqual(len(payload.Events), 5)
+
+		for _, event := range payload.Events {
+			require.Greater(event.Time, lastTimes[event.EventOptions.UserID], "events of %s are out of order", event.EventOptions.UserID)
+			lastTimes[event.EventOptions.UserID] = event.Time
+			sentCount++
+		}
+	}
+
+	require.Equal(eventCount, sentCount)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_PreserveUserEventOrder() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{
+		responses: []internal.AmplitudeResponse{{
+			Status:          http.StatusTooManyRequests,
+			Code:            http.StatusTooManyRequests,
+			ThrottledEvents: []int{0},
+		}},
+	}
+	plugin.SetHTTPClient(httpClient)
+
+	plugin.Setup(types.Config{
+		APIKey:                 "my-api-key",
+		MaxStorageCapacity:     10,
+		FlushInterval:          time.Second * 100,
+		FlushQueueSize:         2,
+		FlushSizeDivider:       1,
+		FlushMaxRetries:        3,
+		RetryThrottledInterval: time.Millisecond * 50,
+		PreserveUserEventOrder: true,
+		StorageFactory:         storages.NewInMemoryEventStorage,
+		Logger:                 noopLogger{},
+	})
+
+	createEvent := func(userID string, eventType string) *types.Event {
+		return &types.Event{EventType: eventType, EventOptions: types.EventOptions{UserID: userID}}
+	}
+
+	// The identify of user-a is throttled, its track must not be sent before it.
+	plugin.Execute(createEvent("user-a", "$identify"))
+	plugin.Execute(createEvent("user-b", "track-1"))
+	plugin.Execute(createEvent("user-a", "track-1"))
+	plugin.Execute(createEvent("user-b", "track-2"))
+	plugin.Flush()
+
+	time.Sleep(time.Millisecond * 100)
+	plugin.Flush()
+	plugin.Shutdown()
+
+	var userAEvents []string
+
+	for _, payload := range httpClient.payloads[1:] {
+		for _, event := range payload.Events {
+			if event.EventOptions.UserID == "user-a" {
+				userAEvents = append(userAEvents, event.EventType)
+			}
+		}
+	}
+
+	t.Require().Equal([]string{"$identify", "track-1"}, userAEvents)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_ResultObserver() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+	plugin.SetHTTPClient(&recordingHTTPClient{})
+
+	var results []types.ExecuteResult
+
+	// Without ExecuteCallback results are observed while sending, not in a callback goroutine.
+	plugin.(interface {
+		SetResultObserver(resultObserver func(result types.ExecuteResult))
+	}).SetResultObserver(func(result types.ExecuteResult) {
+		results = append(results, result)
+	})
+
+	plugin.Setup(types.Config{
+		APIKey:             "my-api-key",
+		MaxStorageCapacity: 10,
+		FlushInterval:      time.Second * 100,
+		FlushQueueSize:     10,
+		FlushSizeDivider:   1,
+		StorageFactory:     storages.NewInMemoryEventStorage,
+		Logger:             noopLogger{},
+	})
+	defer plugin.Shutdown()
+
+	plugin.Execute(&types.Event{EventType: "track-1", EventOptions: types.EventOptions{UserID: "user-a"}})
+	plugin.Flush()
+
+	require := t.Require()
+	require.Len(results, 1)
+	require.Equal(http.StatusOK, results[0].Code)
+	require.Equal("track-1", results[0].Event.EventType)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_PreserveUserEventOrder_NewEventBeforeRetry() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{
+		responses: []internal.AmplitudeResponse{{Status: http.StatusInternalServerError, Code: http.StatusInternalServerError}},
+	}
+	plugin.SetHTTPClient(httpClient)
+
+	plugin.Setup(types.Config{
+		APIKey:                 "my-api-key",
+		MaxStorageCapacity:     10,
+		FlushInterval:          time.Second * 100,
+		FlushQueueSize:         10,
+		FlushSizeDivider:       1,
+		FlushMaxRetries:        3,
+		RetryBaseInterval:      time.Millisecond,
+		PreserveUserEventOrder: true,
+		StorageFactory:         storages.NewInMemoryEventStorage,
+		Logger:                 noopLogger{},
+	})
+
+	plugin.Execute(&types.Event{EventType: "$identify", EventOptions: types.EventOptions{UserID: "user-a"}})
+	plugin.Flush()
+
+	// The retry of the identify is due, and the new track is pulled before it.
+	time.Sleep(time.Millisecond * 50)
+	plugin.Execute(&types.Event{EventType: "track-1", EventOptions: types.EventOptions{UserID: "user-a"}})
+
+	flushed := make(chan struct{})
+
+	go func() {
+		defer close(flushed)
+		plugin.Flush()
+	}()
+
+	require := t.Require()
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second * 5):
+		require.FailNow("Flush is stuck on held events")
+	}
+
+	plugin.Shutdown()
+
+	require.Len(httpClient.payloads, 2)
+	require.Equal("$identify", httpClient.payloads[1].Events[0].EventType)
+	require.Equal("track-1", httpClient.payloads[1].Events[1].EventType)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_CoalesceIdentifies() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{}
+	plugin.SetHTTPClient(httpClient)
+
+	var resultsMu sync.Mutex
+
+	var results []types.ExecuteResult
+
+	plugin.Setup(types.Config{
+		APIKey:             "my-api-key",
+		MaxStorageCapacity: 10,
+		FlushInterval:      time.Second * 100,
+		FlushQueueSize:     10,
+		FlushSizeDivider:   1,
+		FlushMaxRetries:    3,
+		CoalesceIdentifies: true,
+		StorageFactory:     storages.NewInMemoryEventStorage,
+		Logger:             noopLogger{},
+		ExecuteCallback: func(result types.ExecuteResult) {
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+
+			results = append(results, result)
+		},
+	})
+
+	createIdentify := func(userID string, op types.IdentityOp, property string, value interface{}) *types.Event {
+		return &types.Event{
+			EventType:      "$identify",
+			EventOptions:   types.EventOptions{UserID: userID, InsertID: userID + "-" + property},
+			UserProperties: map[types.IdentityOp]map[string]interface{}{op: {property: value}},
+		}
+	}
+
+	plugin.Execute(createIdentify("user-a", types.IdentityOpSet, "plan", "pro"))
+	plugin.Execute(&types.Event{EventType: "track-1", EventOptions: types.EventOptions{UserID: "user-b"}})
+	plugin.Execute(createIdentify("user-a", types.IdentityOpAdd, "visits", 1))
+	plugin.Execute(&types.Event{EventType: "track-1", EventOptions: types.EventOptions{UserID: "user-a"}})
+	plugin.Execute(createIdentify("user-a", types.IdentityOpSet, "seats", 2))
+	plugin.Flush()
+	plugin.Shutdown()
+
+	require := t.Require()
+	require.Len(httpClient.payloads, 1)
+
+	var sent []string
+
+	for _, event := range httpClient.payloads[0].Events {
+		sent = append(sent, event.EventType+" "+event.EventOptions.UserID)
+	}
+
+	require.Equal([]string{"track-1 user-b", "$identify user-a", "track-1 user-a", "$identify user-a"}, sent)
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"plan": "pro"},
+		types.IdentityOpAdd: {"visits": 1},
+	}, httpClient.payloads[0].Events[1].UserProperties)
+
+	var insertIDs []string
+
+	for _, result := range results {
+		require.Equal(http.StatusOK, result.Code)
+
+		insertIDs = append(insertIDs, result.Event.InsertID)
+	}
+
+	require.ElementsMatch([]string{"user-a-plan", "user-a-visits", "user-a-seats", "", ""}, insertIDs)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_AutoBatch() {
+	exceededDailyQuota := internal.AmplitudeResponse{
+		Status:                  http.StatusTooManyRequests,
+		Code:                    http.StatusTooManyRequests,
+		ExceededDailyQuotaUsers: map[string]int{"user-0": 100},
+	}
+
+	tests := []struct {
+		name              string
+		serverURL         string
+		eventCount        int
+		responses         []internal.AmplitudeResponse
+		expectedEndpoints []types.Endpoint
+	}{
+		{
+			name:              "below backlog",
+			eventCount:        2,
+			expectedEndpoints: []types.Endpoint{""},
+		},
+		{
+			name:              "backlog",
+			eventCount:        3,
+			expectedEndpoints: []types.Endpoint{types.EndpointBatch},
+		},
+		{
+			name:              "exceeded daily quota",
+			eventCount:        2,
+			responses:         []internal.AmplitudeResponse{exceededDailyQuota},
+			expectedEndpoints: []types.Endpoint{"", types.EndpointBatch},
+		},
+		{
+			name:              "custom server URL",
+			serverURL:         "https://proxy.example.com/2/httpapi",
+			eventCount:        3,
+			expectedEndpoints: []types.Endpoint{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func() {
+			plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+			httpClient := &recordingHTTPClient{responses: tt.responses}
+			plugin.SetHTTPClient(httpClient)
+
+			results := make(chan types.ExecuteResult, tt.eventCount)
+
+			plugin.Setup(types.Config{
+				APIKey:                 "my-api-key",
+				ServerZone:             types.ServerZoneUS,
+				ServerURL:              tt.serverURL,
+				MaxStorageCapacity:     10,
+				FlushInterval:          time.Second * 100,
+				FlushQueueSize:         10,
+				FlushSizeDivider:       1,
+				FlushMaxRetries:        1,
+				RetryThrottledInterval: time.Millisecond,
+				AutoBatch:              true,
+				AutoBatchBacklog:       3,
+				AutoBatchDuration:      time.Minute,
+				StorageFactory:         storages.NewInMemoryEventStorage,
+				Logger:                 noopLogger{},
+				ExecuteCallback: func(result types.ExecuteResult) {
+					results <- result
+				},
+			})
+
+			for i := 0; i < tt.eventCount; i++ {
+				plugin.Execute(t.createEvent(i))
+			}
+
+			plugin.Flush()
+
+			// Events over the daily quota are retried after the throttled interval.
+			time.Sleep(time.Millisecond * 10)
+			plugin.Shutdown()
+
+			require := t.Require()
+			require.Len(httpClient.payloads, len(tt.expectedEndpoints))
+
+			for i, payload := range httpClient.payloads {
+				require.Equal(tt.expectedEndpoints[i], payload.Endpoint)
+				require.Len(payload.Events, tt.eventCount)
+			}
+
+			expectedEndpoint := tt.expectedEndpoints[len(tt.expectedEndpoints)-1]
+			if expectedEndpoint == "" {
+				expectedEndpoint = types.EndpointHTTPAPI
+			}
+
+			for i := 0; i < tt.eventCount; i++ {
+				result := <-results
+				require.Equal(http.StatusOK, result.Code)
+				require.Equal(expectedEndpoint, result.Endpoint)
+			}
+		})
+	}
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_FlushMaxPayloadBytes() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{}
+	plugin.SetHTTPClient(httpClient)
+
+	maxPayloadBytes := 1000
+
+	plugin.Setup(types.Config{
+		APIKey:               "my-api-key",
+		MaxStorageCapacity:   20,
+		FlushInterval:        time.Second * 100,
+		FlushQueueSize:       20,
+		FlushSizeDivider:     1,
+		FlushMaxRetries:      1,
+		FlushMaxPayloadBytes: maxPayloadBytes,
+		StorageFactory:       storages.NewInMemoryEventStorage,
+		Logger:               noopLogger{},
+	})
+
+	eventCount := 10
+	for i := 0; i < eventCount; i++ {
+		event := t.createEvent(i)
+		if i == 5 {
+			event.EventProperties["large"] = strings.Repeat("x", maxPayloadBytes)
+		}
+
+		plugin.Execute(event)
+	}
+
+	plugin.Flush()
+	plugin.Shutdown()
+
+	require := t.Require()
+	require.Greater(len(httpClient.payloads), 2)
+
+	sentCount := 0
+
+	for _, payload := range httpClient.payloads {
+		sentCount += len(payload.Events)
+
+		payloadBytes, err := json.Marshal(payload)
+		require.NoError(err)
+
+		if len(payloadBytes) > maxPayloadBytes {
+			require.Len(payload.Events, 1)
+			require.Equal("event-5", payload.Events[0].EventType)
+		}
+	}
+
+	require.Equal(eventCount, sentCount)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_ChunkSizeRecovery() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{
+		responses: []internal.AmplitudeResponse{{
+			Status: http.StatusRequestEntityTooLarge,
+			Code:   http.StatusRequestEntityTooLarge,
+		}},
+	}
+	plugin.SetHTTPClient(httpClient)
+
+	plugin.Setup(types.Config{
+		APIKey:                "my-api-key",
+		MaxStorageCapacity:    20,
+		FlushInterval:         time.Second * 100,
+		FlushQueueSize:        4,
+		FlushSizeDivider:      1,
+		FlushMaxRetries:       1,
+		FlushSizeRecoverAfter: 2,
+		StorageFactory:        storages.NewInMemoryEventStorage,
+		Logger:                noopLogger{},
+	})
+
+	for i := 0; i < 8; i++ {
+		plugin.Execute(t.createEvent(i))
+	}
+
+	plugin.Flush()
+	plugin.Shutdown()
+
+	var payloadSizes []int
+	for _, payload := range httpClient.payloads {
+		payloadSizes = append(payloadSizes, len(payload.Events))
+	}
+
+	// The chunk size is halved by the 413 response, then it grows back after two successful requests.
+	t.Require().Equal([]int{4, 2, 2, 4}, payloadSizes)
+}
+
+func (t *AmplitudePluginSuite) TestAmplitudePlugin_TruncateEvents() {
+	plugin := destination.NewAmplitudePlugin().(AmplitudePlugin)
+
+	httpClient := &recordingHTTPClient{}
+	plugin.SetHTTPClient(httpClient)
+
+	results := make(chan types.ExecuteResult, 1)
+
+	plugin.Setup(types.Config{
+		APIKey:             "my-api-key",
+		MaxStorageCapacity: 10,
+		FlushInterval:      time.Second * 100,
+		FlushQueueSize:     10,
+		FlushSizeDivider:   1,
+		TruncateEvents:     true,
+		MaxArrayLength:     2,
+		StorageFactory:     storages.NewInMemoryEventStorage,
+		Logger:             noopLogger{},
+		ExecuteCallback: func(result types.ExecuteResult) {
+			results <- result
+		},
+	})
+
+	event := t.createEvent(1)
+	event.EventProperties["list"] = []string{"a", "b", "c"}
+
+	plugin.Execute(event)
+	plugin.Flush()
+	plugin.Shutdown()
+
+	require := t.Require()
+	require.Len(httpClient.payloads, 1)
+	require.Equal([]string{"a", "b"}, httpClient.payloads[0].Events[0].EventProperties["list"])
+
+	result := <-results
+	require.Equal(http.StatusOK, result.Code)
+	require.Equal("Event sent successfully. Truncated: event_properties.list: array truncated to 2 items", result.Message)
+}
+
 func (t *AmplitudePluginSuite) createEvent(index int) *types.Event {
 	postfix := fmt.Sprintf("-%d", index)
 
@@ -481,6 +1340,44 @@ func (m *mockHTTPClient) Send(payload internal.AmplitudePayload) internal.Amplit
 	return args[0].(internal.AmplitudeResponse)
 }
 
+// recordingHTTPClient accepts every payload after a delay, tracking how many requests are in flight.
+// Queued responses are returned first.
+type recordingHTTPClient struct {
+	delay       time.Duration
+	inFlight    int32
+	maxInFlight int32
+
+	mu        sync.Mutex
+	payloads  []internal.AmplitudePayload
+	responses []internal.AmplitudeResponse
+}
+
+func (c *recordingHTTPClient) Send(payload internal.AmplitudePayload) internal.AmplitudeResponse {
+	inFlight := atomic.AddInt32(&c.inFlight, 1)
+	defer atomic.AddInt32(&c.inFlight, -1)
+
+	for {
+		maxInFlight := atomic.LoadInt32(&c.maxInFlight)
+		if inFlight <= maxInFlight || atomic.CompareAndSwapInt32(&c.maxInFlight, maxInFlight, inFlight) {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.payloads = append(c.payloads, payload)
+
+	response := internal.AmplitudeResponse{Status: http.StatusOK, Code: http.StatusOK}
+	if len(c.responses) > 0 {
+		response = c.responses[0]
+		c.responses = c.responses[1:]
+	}
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	return response
+}
+
 type mockResponseProcessor struct {
 	mock.Mock
 }
@@ -518,6 +1415,24 @@ func (m *mockStorage) Count(before time.Time) int {
 	return args.Int(0)
 }
 
+type mockLeasingStorage struct {
+	mockStorage
+}
+
+func (m *mockLeasingStorage) Lease(count int, before time.Time, timeout time.Duration) *types.EventLease {
+	args := m.Called(count, before, timeout)
+
+	return args[0].(*types.EventLease)
+}
+
+func (m *mockLeasingStorage) Ack(lease *types.EventLease, events ...*types.StorageEvent) {
+	m.Called(lease, events)
+}
+
+func (m *mockLeasingStorage) Nack(lease *types.EventLease, events ...*types.StorageEvent) {
+	m.Called(lease, events)
+}
+
 type noopLogger struct{}
 
 func (l noopLogger) Debugf(string, ...interface{}) {
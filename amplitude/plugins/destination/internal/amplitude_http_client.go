@@ -2,12 +2,17 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/amplitude/analytics-go/amplitude/metrics"
+	"github.com/amplitude/analytics-go/amplitude/tracing"
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
@@ -15,24 +20,61 @@ type AmplitudeHTTPClient interface {
 	Send(payload AmplitudePayload) AmplitudeResponse
 }
 
-func NewAmplitudeHTTPClient(
-	serverURL string, options AmplitudePayloadOptions, logger types.Logger, connectionTimeout time.Duration,
-) AmplitudeHTTPClient {
+func NewAmplitudeHTTPClient(options AmplitudeHTTPClientOptions) AmplitudeHTTPClient {
 	var payloadOptions *AmplitudePayloadOptions
-	if options != (AmplitudePayloadOptions{}) {
-		payloadOptions = &options
+	if options.PayloadOptions != (AmplitudePayloadOptions{}) {
+		payloadOptions = &options.PayloadOptions
+	}
+
+	if options.Metrics == nil {
+		options.Metrics = metrics.NewNoopMetrics()
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   options.ConnectionTimeout,
+			Transport: options.Transport,
+		}
 	}
 
 	return &amplitudeHTTPClient{
-		serverURL:      serverURL,
-		logger:         logger,
-		payloadOptions: payloadOptions,
-		httpClient: &http.Client{
-			Timeout: connectionTimeout,
-		},
+		serverURL:            options.ServerURL,
+		batchServerURL:       options.BatchServerURL,
+		maxPayloadBytes:      options.MaxPayloadBytes,
+		batchMaxPayloadBytes: options.BatchMaxPayloadBytes,
+		logger:               options.Logger,
+		metrics:              options.Metrics,
+		tracer:               tracing.NewTracer(options.TracerProvider),
+		compressor:           options.Compressor,
+		payloadOptions:       payloadOptions,
+		headers:              options.Headers.Clone(),
+		httpClient:           httpClient,
 	}
 }
 
+type AmplitudeHTTPClientOptions struct {
+	ServerURL         string
+	PayloadOptions    AmplitudePayloadOptions
+	Logger            types.Logger
+	ConnectionTimeout time.Duration
+	Metrics           types.Metrics
+	TracerProvider    types.TracerProvider
+	Compressor        types.Compressor
+
+	// BatchServerURL receives payloads with EndpointBatch. Request bodies over MaxPayloadBytes,
+	// or BatchMaxPayloadBytes for the batch endpoint, are rejected with 413 without being sent.
+	// The size of a body is checked after compression.
+	BatchServerURL       string
+	MaxPayloadBytes      int
+	BatchMaxPayloadBytes int
+
+	// HTTPClient is used as is if set, otherwise a client with ConnectionTimeout and Transport is created.
+	HTTPClient *http.Client
+	Transport  http.RoundTripper
+	Headers    http.Header
+}
+
 type AmplitudePayloadOptions struct {
 	MinIDLength int `json:"min_id_length,omitempty"`
 }
@@ -41,13 +83,29 @@ type AmplitudePayload struct {
 	APIKey  string                   `json:"api_key"`
 	Events  []*types.Event           `json:"events"`
 	Options *AmplitudePayloadOptions `json:"options,omitempty"`
+
+	// RetryCount is the highest retry count of the events, reported in the upload span.
+	RetryCount int `json:"-"`
+
+	// Endpoint selects BatchServerURL if it's EndpointBatch, ServerURL is used otherwise.
+	Endpoint types.Endpoint `json:"-"`
 }
 
 type amplitudeHTTPClient struct {
-	serverURL      string
-	logger         types.Logger
-	payloadOptions *AmplitudePayloadOptions
-	httpClient     *http.Client
+	serverURL            string
+	batchServerURL       string
+	maxPayloadBytes      int
+	batchMaxPayloadBytes int
+	logger               types.Logger
+	metrics              types.Metrics
+	tracer               types.Tracer
+	compressor           types.Compressor
+	payloadOptions       *AmplitudePayloadOptions
+	headers              http.Header
+	httpClient           *http.Client
+
+	// compressionDisabled is set atomically once the server rejected a compressed request body.
+	compressionDisabled int32
 }
 
 func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
@@ -55,6 +113,33 @@ func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
 		return AmplitudeResponse{}
 	}
 
+	serverURL, maxPayloadBytes := c.serverURL, c.maxPayloadBytes
+	if payload.Endpoint == types.EndpointBatch && c.batchServerURL != "" {
+		serverURL, maxPayloadBytes = c.batchServerURL, c.batchMaxPayloadBytes
+	}
+
+	_, span := c.tracer.Start(context.Background(), tracing.UploadSpan)
+	defer span.End()
+
+	span.SetAttribute(tracing.ServerURLAttribute, serverURL)
+	span.SetAttribute(tracing.BatchSizeAttribute, len(payload.Events))
+	span.SetAttribute(tracing.RetryCountAttribute, payload.RetryCount)
+
+	response := c.send(serverURL, maxPayloadBytes, payload)
+	response.Endpoint = payload.Endpoint
+
+	if response.Status != 0 {
+		span.SetAttribute(tracing.StatusCodeAttribute, response.Status)
+	}
+
+	if response.Err != nil {
+		span.RecordError(response.Err)
+	}
+
+	return response
+}
+
+func (c *amplitudeHTTPClient) send(serverURL string, maxPayloadBytes int, payload AmplitudePayload) AmplitudeResponse {
 	payload.Options = c.payloadOptions
 	payloadBytes, err := json.Marshal(payload)
 
@@ -68,7 +153,72 @@ func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
 
 	c.logger.Debugf("payloadBytes:\n\t%s", string(payloadBytes))
 
-	request, err := http.NewRequest(http.MethodPost, c.serverURL, bytes.NewReader(payloadBytes))
+	body, contentEncoding := c.compress(payloadBytes)
+	if response, ok := c.checkPayloadSize(body, contentEncoding, maxPayloadBytes); !ok {
+		return response
+	}
+
+	response := c.post(serverURL, body, contentEncoding)
+
+	if contentEncoding != "" {
+		switch response.Status {
+		case http.StatusUnsupportedMediaType:
+			c.logger.Warnf("Server doesn't accept %s request body, compression is disabled", contentEncoding)
+			atomic.StoreInt32(&c.compressionDisabled, 1)
+
+			if response, ok := c.checkPayloadSize(payloadBytes, "", maxPayloadBytes); !ok {
+				return response
+			}
+
+			return c.post(serverURL, payloadBytes, "")
+		case http.StatusRequestEntityTooLarge:
+			c.logger.Warnf("RequestEntityTooLarge: payload of %d bytes is %d bytes with %s", len(payloadBytes), len(body), contentEncoding)
+		}
+	}
+
+	return response
+}
+
+// checkPayloadSize returns a 413 response and false if the request body exceeds maxPayloadBytes,
+// the body is compressed if contentEncoding isn't empty.
+func (c *amplitudeHTTPClient) checkPayloadSize(body []byte, contentEncoding string, maxPayloadBytes int) (AmplitudeResponse, bool) {
+	if maxPayloadBytes <= 0 || len(body) <= maxPayloadBytes {
+		return AmplitudeResponse{}, true
+	}
+
+	size := fmt.Sprintf("%d bytes", len(body))
+	if contentEncoding != "" {
+		size += " with " + contentEncoding
+	}
+
+	c.logger.Warnf("RequestEntityTooLarge: payload of %s exceeds the limit of %d bytes, it's not sent", size, maxPayloadBytes)
+
+	return AmplitudeResponse{
+		Status: http.StatusRequestEntityTooLarge,
+		Code:   http.StatusRequestEntityTooLarge,
+		Error:  fmt.Sprintf("Payload of %s exceeds the limit of %d bytes", size, maxPayloadBytes),
+	}, false
+}
+
+// compress returns the compressed body and its content encoding,
+// or the body as is and an empty content encoding if compression is not used.
+func (c *amplitudeHTTPClient) compress(body []byte) ([]byte, string) {
+	if c.compressor == nil || atomic.LoadInt32(&c.compressionDisabled) == 1 {
+		return body, ""
+	}
+
+	compressedBody, err := c.compressor.Compress(body)
+	if err != nil {
+		c.logger.Warnf("Compressing payload with %s failed, sending it uncompressed: %s", c.compressor.ContentEncoding(), err)
+
+		return body, ""
+	}
+
+	return compressedBody, c.compressor.ContentEncoding()
+}
+
+func (c *amplitudeHTTPClient) post(serverURL string, body []byte, contentEncoding string) AmplitudeResponse {
+	request, err := http.NewRequest(http.MethodPost, serverURL, bytes.NewReader(body))
 	if err != nil {
 		c.logger.Errorf("Building new request failed: \n\t%w", err)
 
@@ -77,10 +227,23 @@ func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
 		}
 	}
 
+	for name, values := range c.headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "*/*")
 
+	if contentEncoding != "" {
+		request.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	startTime := time.Now()
 	response, err := c.httpClient.Do(request)
+	c.observeRequest(response, time.Since(startTime))
+
 	if err != nil {
 		return AmplitudeResponse{
 			Err: fmt.Errorf("HTTP request failed: %w", err),
@@ -96,7 +259,7 @@ func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
 
 	c.logger.Infof("HTTP response code: %s", response.Status)
 
-	body, err := io.ReadAll(response.Body)
+	responseBody, err := io.ReadAll(response.Body)
 	if err != nil {
 		return AmplitudeResponse{
 			Status: response.StatusCode,
@@ -104,17 +267,29 @@ func (c *amplitudeHTTPClient) Send(payload AmplitudePayload) AmplitudeResponse {
 		}
 	}
 
-	c.logger.Infof("HTTP response body: %s", string(body))
+	c.logger.Infof("HTTP response body: %s", string(responseBody))
 
 	var amplitudeResponse AmplitudeResponse
-	if json.Valid(body) {
-		_ = json.Unmarshal(body, &amplitudeResponse)
+	if json.Valid(responseBody) {
+		_ = json.Unmarshal(responseBody, &amplitudeResponse)
 	} else {
-		c.logger.Debugf("HTTP response body is not valid JSON: %s", string(body))
+		c.logger.Debugf("HTTP response body is not valid JSON: %s", string(responseBody))
 		amplitudeResponse.Code = response.StatusCode
 	}
 
 	amplitudeResponse.Status = response.StatusCode
+	amplitudeResponse.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
 
 	return amplitudeResponse
 }
+
+func (c *amplitudeHTTPClient) observeRequest(response *http.Response, duration time.Duration) {
+	status := "error"
+	if response != nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+
+	labels := map[string]string{"status": status}
+	c.metrics.AddCounter(metrics.HTTPRequestsTotal, 1, labels)
+	c.metrics.ObserveHistogram(metrics.HTTPRequestDuration, duration.Seconds(), labels)
+}
@@ -1,6 +1,7 @@
 package internal_test
 
 import (
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,11 +10,13 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
 
+	"github.com/amplitude/analytics-go/amplitude/compression"
 	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
@@ -29,12 +32,12 @@ type AmplitudeHTTPClientSuiteSuite struct {
 func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Success() {
 	server := t.createTestServer(0, 200, `{"code": 234, "error": "some server error"}`)
 
-	client := internal.NewAmplitudeHTTPClient(
-		server.URL,
-		internal.AmplitudePayloadOptions{MinIDLength: 7},
-		noopLogger{},
-		time.Millisecond*1000,
-	)
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		PayloadOptions:    internal.AmplitudePayloadOptions{MinIDLength: 7},
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+	})
 
 	response := client.Send(internal.AmplitudePayload{
 		APIKey: "my-api-key",
@@ -98,12 +101,12 @@ func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Success() {
 func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Empty() {
 	server := t.createTestServer(0, 200, `{"code": 234, "error": "some server error"}`)
 
-	client := internal.NewAmplitudeHTTPClient(
-		server.URL,
-		internal.AmplitudePayloadOptions{MinIDLength: 7},
-		noopLogger{},
-		time.Millisecond*1000,
-	)
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		PayloadOptions:    internal.AmplitudePayloadOptions{MinIDLength: 7},
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+	})
 
 	response := client.Send(internal.AmplitudePayload{
 		APIKey: "my-api-key",
@@ -124,12 +127,12 @@ func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Timeout() {
 	timeout := time.Millisecond * 100
 	server := t.createTestServer(timeout * 2, 200, `{"code": 234, "error": "some server error"}`)
 
-	client := internal.NewAmplitudeHTTPClient(
-		server.URL,
-		internal.AmplitudePayloadOptions{MinIDLength: 7},
-		noopLogger{},
-		timeout,
-	)
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		PayloadOptions:    internal.AmplitudePayloadOptions{MinIDLength: 7},
+		Logger:            noopLogger{},
+		ConnectionTimeout: timeout,
+	})
 
 	response := client.Send(internal.AmplitudePayload{
 		APIKey: "my-api-key",
@@ -156,12 +159,12 @@ func (t *AmplitudeHTTPClientSuiteSuite) TestSend_NonJsonResponse() {
 	</body>
 	</html>`)
 
-	client := internal.NewAmplitudeHTTPClient(
-		server.URL,
-		internal.AmplitudePayloadOptions{MinIDLength: 7},
-		noopLogger{},
-		time.Millisecond*1000,
-	)
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		PayloadOptions:    internal.AmplitudePayloadOptions{MinIDLength: 7},
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+	})
 
 	response := client.Send(internal.AmplitudePayload{
 		APIKey: "my-api-key",
@@ -201,6 +204,259 @@ func (t *AmplitudeHTTPClientSuiteSuite) TestSend_NonJsonResponse() {
 }`, server.payloads[0])
 }
 
+func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Compression() {
+	var contentEncodings []string
+	var payloads []string
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			t.Require().NoError(err)
+
+			body = reader
+		}
+
+		payload, err := io.ReadAll(body)
+		t.Require().NoError(err)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		contentEncodings = append(contentEncodings, r.Header.Get("Content-Encoding"))
+		payloads = append(payloads, string(payload))
+
+		// The first request is rejected to check the fallback to uncompressed requests.
+		if len(payloads) == 1 {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"code": 200}`))
+	}))
+	defer server.Close()
+
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+		Compressor:        compression.NewGzipCompressor(),
+	})
+
+	payload := internal.AmplitudePayload{
+		APIKey: "my-api-key",
+		Events: []*types.Event{t.createEvent(1)},
+	}
+
+	require := t.Require()
+
+	response := client.Send(payload)
+	require.Equal(http.StatusOK, response.Status)
+
+	response = client.Send(payload)
+	require.Equal(http.StatusOK, response.Status)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Equal([]string{"gzip", "", ""}, contentEncodings)
+	require.Len(payloads, 3)
+
+	for _, payload := range payloads {
+		require.JSONEq(`
+{
+  "api_key": "my-api-key",
+  "events": [
+    {
+      "event_type": "event-1",
+      "user_id": "user-1",
+      "time": 1,
+      "insert_id": "insert-1",
+      "event_properties": {
+        "prop-1": 1
+      }
+    }
+  ]
+}`, payload)
+	}
+}
+
+func (t *AmplitudeHTTPClientSuiteSuite) TestSend_CustomTransportAndHeaders() {
+	var headers []http.Header
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		headers = append(headers, r.Header.Clone())
+
+		_, _ = w.Write([]byte(`{"code": 200}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	customHeaders := http.Header{"x-proxy-authorization": {"token"}, "Content-Type": {"text/plain"}}
+
+	payload := internal.AmplitudePayload{
+		APIKey: "my-api-key",
+		Events: []*types.Event{t.createEvent(1)},
+	}
+
+	require := t.Require()
+
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+		Transport:         transport,
+		Headers:           customHeaders,
+	})
+	require.Equal(http.StatusOK, client.Send(payload).Status)
+
+	client = internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:  server.URL,
+		Logger:     noopLogger{},
+		HTTPClient: &http.Client{Transport: transport},
+	})
+	require.Equal(http.StatusOK, client.Send(payload).Status)
+
+	require.Equal(2, transport.requests)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(headers, 2)
+	require.Equal("token", headers[0].Get("X-Proxy-Authorization"))
+	require.Equal("application/json", headers[0].Get("Content-Type"))
+	require.Empty(headers[1].Get("X-Proxy-Authorization"))
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.requests++
+
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func (t *AmplitudeHTTPClientSuiteSuite) TestSend_RetryAfter() {
+	retryAfterHeaders := []string{"120", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), "invalid"}
+
+	require := t.Require()
+
+	for i, retryAfterHeader := range retryAfterHeaders {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", retryAfterHeader)
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code": 429}`))
+		}))
+
+		client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+			ServerURL:         server.URL,
+			Logger:            noopLogger{},
+			ConnectionTimeout: time.Millisecond * 1000,
+		})
+
+		response := client.Send(internal.AmplitudePayload{
+			APIKey: "my-api-key",
+			Events: []*types.Event{t.createEvent(1)},
+		})
+
+		server.Close()
+
+		require.Equal(http.StatusTooManyRequests, response.Status)
+
+		switch i {
+		case 0:
+			require.Equal(time.Second*120, response.RetryAfter)
+		case 1:
+			require.InDelta(time.Hour, response.RetryAfter, float64(time.Second*5))
+		default:
+			require.Zero(response.RetryAfter)
+		}
+	}
+}
+
+func (t *AmplitudeHTTPClientSuiteSuite) TestSend_Endpoint() {
+	server := t.createTestServer(0, http.StatusOK, `{"code": 200}`)
+	defer server.Close()
+
+	batchServer := t.createTestServer(0, http.StatusOK, `{"code": 200}`)
+	defer batchServer.Close()
+
+	client := internal.NewAmplitudeHTTPClient(internal.AmplitudeHTTPClientOptions{
+		ServerURL:            server.URL,
+		BatchServerURL:       batchServer.URL,
+		MaxPayloadBytes:      200,
+		BatchMaxPayloadBytes: 2000,
+		Logger:               noopLogger{},
+		ConnectionTimeout:    time.Millisecond * 1000,
+	})
+
+	events := make([]*types.Event, 5)
+	for i := range events {
+		events[i] = t.createEvent(i)
+	}
+
+	require := t.Require()
+
+	response := client.Send(internal.AmplitudePayload{APIKey: "my-api-key", Events: events})
+	require.Equal(http.StatusRequestEntityTooLarge, response.Status)
+	require.Equal(http.StatusRequestEntityTooLarge, response.Code)
+	require.Empty(response.Endpoint)
+
+	response = client.Send(internal.AmplitudePayload{APIKey: "my-api-key", Events: events, Endpoint: types.EndpointBatch})
+	require.Equal(http.StatusOK, response.Status)
+	require.Equal(types.EndpointBatch, response.Endpoint)
+
+	response = client.Send(internal.AmplitudePayload{APIKey: "my-api-key", Events: events[:1]})
+	require.Equal(http.StatusOK, response.Status)
+
+	require.Len(server.payloads, 1)
+	require.Len(batchServer.payloads, 1)
+}
+
+func (t *AmplitudeHTTPClientSuiteSuite) TestSend_MaxPayloadBytesCompressed() {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"code": 200}`))
+	}))
+	defer server.Close()
+
+	options := internal.AmplitudeHTTPClientOptions{
+		ServerURL:         server.URL,
+		MaxPayloadBytes:   400,
+		Logger:            noopLogger{},
+		ConnectionTimeout: time.Millisecond * 1000,
+	}
+
+	events := make([]*types.Event, 10)
+	for i := range events {
+		events[i] = t.createEvent(1)
+	}
+
+	require := t.Require()
+
+	// The uncompressed payload is over the limit.
+	response := internal.NewAmplitudeHTTPClient(options).Send(internal.AmplitudePayload{APIKey: "my-api-key", Events: events})
+	require.Equal(http.StatusRequestEntityTooLarge, response.Status)
+
+	// The compressed payload sent is within the limit.
+	options.Compressor = compression.NewGzipCompressor()
+	response = internal.NewAmplitudeHTTPClient(options).Send(internal.AmplitudePayload{APIKey: "my-api-key", Events: events})
+	require.Equal(http.StatusOK, response.Status)
+
+	require.Equal(int32(1), atomic.LoadInt32(&requests))
+}
+
 type testServer struct {
 	*httptest.Server
 	mu       sync.Mutex
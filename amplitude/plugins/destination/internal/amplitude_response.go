@@ -2,6 +2,9 @@ package internal
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
@@ -10,6 +13,12 @@ type AmplitudeResponse struct {
 	Status int   `json:"-"`
 	Err    error `json:"-"`
 
+	// RetryAfter is the delay requested by the Retry-After header of the response.
+	RetryAfter time.Duration `json:"-"`
+
+	// Endpoint is the Endpoint of the payload the response is for.
+	Endpoint types.Endpoint `json:"-"`
+
 	Code  int    `json:"code"`
 	Error string `json:"error"`
 
@@ -43,6 +52,27 @@ func (r AmplitudeResponse) normalizedStatus() int {
 	}
 }
 
+// IsSuccess reports whether the events were accepted.
+func (r AmplitudeResponse) IsSuccess() bool {
+	return r.Err == nil && r.normalizedStatus() == http.StatusOK
+}
+
+// IsServerFailure reports whether the request failed in transport or the server was unavailable.
+func (r AmplitudeResponse) IsServerFailure() bool {
+	if r.Err != nil {
+		return true
+	}
+
+	status := r.normalizedStatus()
+
+	return status == http.StatusInternalServerError || status == http.StatusRequestTimeout
+}
+
+// HasExceededDailyQuota reports whether events were throttled because a user or device exceeded its daily quota.
+func (r AmplitudeResponse) HasExceededDailyQuota() bool {
+	return r.Status == http.StatusTooManyRequests && (len(r.ExceededDailyQuotaUsers) > 0 || len(r.ExceededDailyQuotaDevices) > 0)
+}
+
 func (r AmplitudeResponse) invalidOrSilencedEventIndexes() map[int]struct{} {
 	result := make(map[int]struct{})
 
@@ -92,3 +122,25 @@ func (r AmplitudeResponse) hasExceededDailyQuota(event *types.Event) bool {
 
 	return false
 }
+
+// parseRetryAfter parses the Retry-After header, given in seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
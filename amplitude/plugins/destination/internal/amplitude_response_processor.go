@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/amplitude/analytics-go/amplitude/metrics"
 	"github.com/amplitude/analytics-go/amplitude/types"
 )
 
@@ -24,6 +27,14 @@ type AmplitudeProcessorResult struct {
 }
 
 func NewAmplitudeResponseProcessor(options AmplitudeResponseProcessorOptions) AmplitudeResponseProcessor {
+	if options.Metrics == nil {
+		options.Metrics = metrics.NewNoopMetrics()
+	}
+
+	if options.Rand == nil {
+		options.Rand = rand.Float64
+	}
+
 	return &amplitudeResponseProcessor{
 		Options: options,
 	}
@@ -33,8 +44,18 @@ type AmplitudeResponseProcessorOptions struct {
 	MaxRetries             int
 	RetryBaseInterval      time.Duration
 	RetryThrottledInterval time.Duration
+	RetryMaxInterval       time.Duration
+	BackoffStrategy        types.BackoffStrategy
 	Now                    func() time.Time
+	Rand                   func() float64
 	Logger                 types.Logger
+	Metrics                types.Metrics
+
+	// RetryExceededDailyQuota retries events over the daily quota unless they were sent to the batch endpoint.
+	RetryExceededDailyQuota bool
+
+	// SalvageLimits truncate a single event rejected as too large, it's retried if anything was truncated.
+	SalvageLimits TruncationLimits
 }
 
 type amplitudeResponseProcessor struct {
@@ -47,7 +68,7 @@ func (p *amplitudeResponseProcessor) Process(events []*types.StorageEvent, respo
 	var urlErr *url.Error
 	isURLErr := errors.As(response.Err, &urlErr)
 
-	isSuccess := response.Err == nil && responseStatus == http.StatusOK
+	isSuccess := response.IsSuccess()
 
 	var result AmplitudeProcessorResult
 
@@ -71,9 +92,29 @@ func (p *amplitudeResponseProcessor) Process(events []*types.StorageEvent, respo
 		p.Options.Logger.Errorf("%s: code=%d, events=%s", result.Message, result.Code, eventsJSON)
 	}
 
+	p.recordMetrics(isSuccess, result)
+
 	return result
 }
 
+func (p *amplitudeResponseProcessor) recordMetrics(isSuccess bool, result AmplitudeProcessorResult) {
+	if len(result.EventsForCallback) > 0 {
+		sentResult := "failed"
+		if isSuccess {
+			sentResult = "delivered"
+		}
+
+		p.Options.Metrics.AddCounter(metrics.EventsSentTotal, float64(len(result.EventsForCallback)), map[string]string{
+			"result": sentResult,
+			"code":   strconv.Itoa(result.Code),
+		})
+	}
+
+	if len(result.EventsForRetry) > 0 {
+		p.Options.Metrics.AddCounter(metrics.EventsRetriedTotal, float64(len(result.EventsForRetry)), nil)
+	}
+}
+
 func (p *amplitudeResponseProcessor) processSuccess(events []*types.StorageEvent, response AmplitudeResponse) AmplitudeProcessorResult {
 	return AmplitudeProcessorResult{
 		Code:              response.Code,
@@ -92,7 +133,14 @@ func (p *amplitudeResponseProcessor) processTimeout(events []*types.StorageEvent
 			eventsForCallback = append(eventsForCallback, event)
 		} else {
 			event.RetryCount++
-			event.RetryAt = now.Add(p.retryInterval(event.RetryCount))
+
+			retryInterval := p.retryInterval(event)
+			if response.RetryAfter > retryInterval {
+				retryInterval = response.RetryAfter
+			}
+
+			event.RetryInterval = retryInterval
+			event.RetryAt = now.Add(retryInterval)
 			eventsForRetry = append(eventsForRetry, event)
 		}
 	}
@@ -109,6 +157,17 @@ func (p *amplitudeResponseProcessor) processTimeout(events []*types.StorageEvent
 
 func (p *amplitudeResponseProcessor) processTooLargeRequest(events []*types.StorageEvent, response AmplitudeResponse) AmplitudeProcessorResult {
 	if len(events) == 1 {
+		if truncations := TruncateEvent(events[0].Event, p.Options.SalvageLimits); len(truncations) > 0 {
+			p.Options.Logger.Warnf("RequestEntityTooLarge: event is truncated to be retried: %s", strings.Join(truncations, "; "))
+			events[0].Truncations = append(events[0].Truncations, truncations...)
+
+			return AmplitudeProcessorResult{
+				Code:           response.Code,
+				Message:        response.Error,
+				EventsForRetry: events,
+			}
+		}
+
 		result := AmplitudeProcessorResult{
 			Code:              response.Code,
 			Message:           response.Error,
@@ -175,12 +234,25 @@ func (p *amplitudeResponseProcessor) processTooManyRequests(events []*types.Stor
 	eventsForRetryDelay := make([]*types.StorageEvent, 0, len(events))
 	now := p.Options.Now()
 
+	throttledInterval := p.Options.RetryThrottledInterval
+	if response.RetryAfter > 0 {
+		throttledInterval = response.RetryAfter
+	}
+
 	for i, event := range events {
 		if response.hasThrottledEventAtIndex(i) {
 			if response.hasExceededDailyQuota(event.Event) {
-				eventsForCallback = append(eventsForCallback, event)
+				if p.Options.RetryExceededDailyQuota && response.Endpoint != types.EndpointBatch && event.RetryCount < p.Options.MaxRetries {
+					event.RetryCount++
+					event.RetryInterval = throttledInterval
+					event.RetryAt = now.Add(throttledInterval)
+					eventsForRetry = append(eventsForRetry, event)
+				} else {
+					eventsForCallback = append(eventsForCallback, event)
+				}
 			} else {
-				event.RetryAt = now.Add(p.Options.RetryThrottledInterval)
+				event.RetryInterval = throttledInterval
+				event.RetryAt = now.Add(throttledInterval)
 				eventsForRetryDelay = append(eventsForRetryDelay, event)
 			}
 		} else {
@@ -188,6 +260,10 @@ func (p *amplitudeResponseProcessor) processTooManyRequests(events []*types.Stor
 		}
 	}
 
+	if len(eventsForRetryDelay) > 0 {
+		p.Options.Metrics.AddCounter(metrics.EventsThrottledTotal, float64(len(eventsForRetryDelay)), nil)
+	}
+
 	result := AmplitudeProcessorResult{
 		Code:              response.Code,
 		Message:           "Exceeded daily quota",
@@ -217,6 +293,36 @@ func (p *amplitudeResponseProcessor) processUnknownError(events []*types.Storage
 	return result
 }
 
-func (p *amplitudeResponseProcessor) retryInterval(retries int) time.Duration {
+// retryInterval returns the interval before the next retry of the event, its RetryCount is already increased.
+func (p *amplitudeResponseProcessor) retryInterval(event *types.StorageEvent) time.Duration {
+	var interval time.Duration
+
+	switch p.Options.BackoffStrategy {
+	case types.BackoffFullJitter:
+		interval = time.Duration(p.Options.Rand() * float64(p.capInterval(p.exponentialInterval(event.RetryCount))))
+	case types.BackoffDecorrelatedJitter:
+		previousInterval := event.RetryInterval
+		if previousInterval < p.Options.RetryBaseInterval {
+			previousInterval = p.Options.RetryBaseInterval
+		}
+
+		spread := float64(previousInterval*3 - p.Options.RetryBaseInterval)
+		interval = p.Options.RetryBaseInterval + time.Duration(p.Options.Rand()*spread)
+	default:
+		interval = p.exponentialInterval(event.RetryCount)
+	}
+
+	return p.capInterval(interval)
+}
+
+func (p *amplitudeResponseProcessor) exponentialInterval(retries int) time.Duration {
 	return p.Options.RetryBaseInterval * (1 << ((retries - 1) / 2))
 }
+
+func (p *amplitudeResponseProcessor) capInterval(interval time.Duration) time.Duration {
+	if p.Options.RetryMaxInterval > 0 && interval > p.Options.RetryMaxInterval {
+		return p.Options.RetryMaxInterval
+	}
+
+	return interval
+}
@@ -122,6 +122,39 @@ func (t *AmplitudeResponseProcessorSuite) TestTooLargeRequest_OneEvent() {
 	require.Equal(0, len(result.EventsForRetry))
 }
 
+func (t *AmplitudeResponseProcessorSuite) TestTooLargeRequest_SalvageOneEvent() {
+	events := []*types.StorageEvent{{
+		Event: &types.Event{
+			EventType:       "event-A",
+			EventProperties: map[string]interface{}{"property-1": "long value"},
+		},
+	}}
+
+	p := internal.NewAmplitudeResponseProcessor(internal.AmplitudeResponseProcessorOptions{
+		Logger:        loggers.NewDefaultLogger(),
+		SalvageLimits: internal.TruncationLimits{MaxStringLength: 4},
+	})
+
+	response := internal.AmplitudeResponse{
+		Status: http.StatusRequestEntityTooLarge,
+		Code:   413,
+		Error:  "too large",
+	}
+
+	require := t.Require()
+
+	result := p.Process(events, response)
+	require.Empty(result.EventsForCallback)
+	require.Equal(events, result.EventsForRetry)
+	require.Equal("long", events[0].EventProperties["property-1"])
+	require.Equal([]string{"event_properties.property-1: string truncated to 4 characters"}, events[0].Truncations)
+
+	// Nothing is left to truncate.
+	result = p.Process(events, response)
+	require.Equal(events, result.EventsForCallback)
+	require.Empty(result.EventsForRetry)
+}
+
 func (t *AmplitudeResponseProcessorSuite) TestTooLargeRequest() {
 	events := t.cloneOriginalEvents()
 
@@ -283,6 +316,165 @@ func (t *AmplitudeResponseProcessorSuite) TestTooManyRequests() {
 	require.Equal(now.Add(retryThrottledInterval), events[2].RetryAt)
 }
 
+func (t *AmplitudeResponseProcessorSuite) TestTooManyRequests_RetryExceededDailyQuota() {
+	now := time.Now()
+
+	p := internal.NewAmplitudeResponseProcessor(internal.AmplitudeResponseProcessorOptions{
+		MaxRetries:              1,
+		RetryThrottledInterval:  time.Second,
+		Now:                     func() time.Time { return now },
+		Logger:                  loggers.NewDefaultLogger(),
+		RetryExceededDailyQuota: true,
+	})
+
+	response := internal.AmplitudeResponse{
+		Status:                  http.StatusTooManyRequests,
+		Code:                    429,
+		ThrottledEvents:         []int{1},
+		ExceededDailyQuotaUsers: map[string]int{"user-2": 100},
+	}
+
+	require := t.Require()
+
+	events := t.cloneOriginalEvents()
+	result := p.Process(events, response)
+	require.Empty(result.EventsForCallback)
+	require.Len(result.EventsForRetry, 3)
+	require.Equal(1, events[1].RetryCount)
+	require.Equal(now.Add(time.Second), events[1].RetryAt)
+	require.Equal(time.Second, events[1].RetryInterval)
+
+	// Retry-After takes precedence over the throttled interval.
+	events = t.cloneOriginalEvents()
+	response.RetryAfter = time.Minute
+	p.Process(events, response)
+	require.Equal(now.Add(time.Minute), events[1].RetryAt)
+	response.RetryAfter = 0
+
+	// The event reached max retries.
+	result = p.Process(events, response)
+	require.Equal([]*types.StorageEvent{events[1]}, result.EventsForCallback)
+
+	// The batch endpoint doesn't accept the event either.
+	events = t.cloneOriginalEvents()
+	response.Endpoint = types.EndpointBatch
+	result = p.Process(events, response)
+	require.Equal([]*types.StorageEvent{events[1]}, result.EventsForCallback)
+}
+
+func (t *AmplitudeResponseProcessorSuite) TestRetryAfter() {
+	now := time.Now()
+	retryAfter := time.Second * 42
+
+	p := internal.NewAmplitudeResponseProcessor(internal.AmplitudeResponseProcessorOptions{
+		MaxRetries:             3,
+		RetryBaseInterval:      time.Second,
+		RetryThrottledInterval: time.Second * 7,
+		Now:                    func() time.Time { return now },
+		Logger:                 loggers.NewDefaultLogger(),
+	})
+
+	require := t.Require()
+
+	events := t.cloneOriginalEvents()
+	result := p.Process(events, internal.AmplitudeResponse{
+		Status:          http.StatusTooManyRequests,
+		Code:            429,
+		ThrottledEvents: []int{0},
+		RetryAfter:      retryAfter,
+	})
+	require.Len(result.EventsForRetry, 3)
+	require.Equal(now.Add(retryAfter), events[0].RetryAt)
+	require.Equal(retryAfter, events[0].RetryInterval)
+
+	events = t.cloneOriginalEvents()
+	result = p.Process(events, internal.AmplitudeResponse{
+		Status:     http.StatusServiceUnavailable,
+		Code:       503,
+		RetryAfter: retryAfter,
+	})
+	require.Len(result.EventsForRetry, 3)
+
+	for _, event := range result.EventsForRetry {
+		require.Equal(now.Add(retryAfter), event.RetryAt)
+	}
+}
+
+func (t *AmplitudeResponseProcessorSuite) TestBackoffStrategy() {
+	now := time.Now()
+	retryBaseInterval := time.Second
+
+	tests := []struct {
+		name             string
+		strategy         types.BackoffStrategy
+		retryCount       int
+		retryInterval    time.Duration
+		expectedInterval time.Duration
+	}{
+		{
+			name:             "exponential",
+			strategy:         types.BackoffExponential,
+			retryCount:       5,
+			expectedInterval: time.Second * 4,
+		},
+		{
+			name:             "exponential capped",
+			strategy:         types.BackoffExponential,
+			retryCount:       10,
+			expectedInterval: time.Second * 10,
+		},
+		{
+			name:             "full jitter",
+			strategy:         types.BackoffFullJitter,
+			retryCount:       5,
+			expectedInterval: time.Second * 2,
+		},
+		{
+			name:             "decorrelated jitter",
+			strategy:         types.BackoffDecorrelatedJitter,
+			retryCount:       2,
+			retryInterval:    time.Second * 3,
+			expectedInterval: time.Second * 5,
+		},
+		{
+			name:             "decorrelated jitter capped",
+			strategy:         types.BackoffDecorrelatedJitter,
+			retryCount:       2,
+			retryInterval:    time.Second * 9,
+			expectedInterval: time.Second * 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func() {
+			p := internal.NewAmplitudeResponseProcessor(internal.AmplitudeResponseProcessorOptions{
+				MaxRetries:        20,
+				RetryBaseInterval: retryBaseInterval,
+				RetryMaxInterval:  time.Second * 10,
+				BackoffStrategy:   tt.strategy,
+				Now:               func() time.Time { return now },
+				Rand:              func() float64 { return 0.5 },
+				Logger:            loggers.NewDefaultLogger(),
+			})
+
+			events := t.cloneOriginalEvents()[:1]
+			events[0].RetryCount = tt.retryCount - 1
+			events[0].RetryInterval = tt.retryInterval
+
+			result := p.Process(events, internal.AmplitudeResponse{
+				Status: http.StatusInternalServerError,
+				Code:   500,
+			})
+
+			require := t.Require()
+			require.Len(result.EventsForRetry, 1)
+			require.Equal(tt.retryCount, events[0].RetryCount)
+			require.Equal(tt.expectedInterval, events[0].RetryInterval)
+			require.Equal(now.Add(tt.expectedInterval), events[0].RetryAt)
+		})
+	}
+}
+
 func (t *AmplitudeResponseProcessorSuite) TestProcessUnknownError_Err() {
 	events := t.cloneOriginalEvents()
 
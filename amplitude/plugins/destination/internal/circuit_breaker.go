@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+type CircuitState = types.CircuitBreakerState
+
+const (
+	CircuitClosed   = types.CircuitBreakerClosed
+	CircuitOpen     = types.CircuitBreakerOpen
+	CircuitHalfOpen = types.CircuitBreakerHalfOpen
+)
+
+// CircuitBreaker stops requests after consecutive failures.
+// Once OpenTimeout passed, a single probe request is allowed, it closes the circuit if it succeeds.
+type CircuitBreaker interface {
+	// Allow reports whether a request may be sent. Its result must be recorded if it returns true.
+	Allow() bool
+	RecordSuccess()
+	RecordFailure()
+	State() CircuitState
+}
+
+type CircuitBreakerOptions struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	Now              func() time.Time
+
+	// OnStateChange is called with the new state while the circuit breaker is locked, it must not block.
+	OnStateChange func(state CircuitState)
+}
+
+func NewCircuitBreaker(options CircuitBreakerOptions) CircuitBreaker {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &circuitBreaker{options: options}
+}
+
+type circuitBreaker struct {
+	options  CircuitBreakerOptions
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	mu       sync.Mutex
+}
+
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.options.Now().Sub(b.openedAt) < b.options.OpenTimeout {
+			return false
+		}
+
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+
+	if b.state != CircuitClosed {
+		b.setState(CircuitClosed)
+	}
+}
+
+func (b *circuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.options.FailureThreshold) {
+		b.openedAt = b.options.Now()
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+
+	if b.options.OnStateChange != nil {
+		b.options.OnStateChange(state)
+	}
+}
@@ -0,0 +1,71 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	suite.Run(t, new(CircuitBreakerSuite))
+}
+
+type CircuitBreakerSuite struct {
+	suite.Suite
+}
+
+func (t *CircuitBreakerSuite) TestStateTransitions() {
+	now := time.Now()
+
+	var states []internal.CircuitState
+
+	b := internal.NewCircuitBreaker(internal.CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Second * 10,
+		Now:              func() time.Time { return now },
+		OnStateChange: func(state internal.CircuitState) {
+			states = append(states, state)
+		},
+	})
+
+	require := t.Require()
+
+	require.True(b.Allow())
+	b.RecordFailure()
+	require.True(b.Allow())
+	b.RecordSuccess()
+	require.Equal(internal.CircuitClosed, b.State())
+
+	require.True(b.Allow())
+	b.RecordFailure()
+	require.True(b.Allow())
+	b.RecordFailure()
+	require.Equal(internal.CircuitOpen, b.State())
+	require.False(b.Allow())
+
+	now = now.Add(time.Second * 10)
+	require.True(b.Allow())
+	require.Equal(internal.CircuitHalfOpen, b.State())
+	require.False(b.Allow(), "only one probe is allowed")
+
+	b.RecordFailure()
+	require.Equal(internal.CircuitOpen, b.State())
+	require.False(b.Allow())
+
+	now = now.Add(time.Second * 10)
+	require.True(b.Allow())
+	b.RecordSuccess()
+	require.Equal(internal.CircuitClosed, b.State())
+	require.True(b.Allow())
+
+	require.Equal([]internal.CircuitState{
+		internal.CircuitOpen,
+		internal.CircuitHalfOpen,
+		internal.CircuitOpen,
+		internal.CircuitHalfOpen,
+		internal.CircuitClosed,
+	}, states)
+}
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// EventOrderKeeper holds back events of a user while earlier events of the user wait for a retry,
+// so events of a user are sent in the order they were tracked.
+type EventOrderKeeper interface {
+	// Hold splits a chunk into events ready to be sent and held events.
+	// RetryAt of held events is set, so they are pulled after pending retries of their user.
+	Hold(events []*types.StorageEvent) (ready []*types.StorageEvent, held []*types.StorageEvent)
+	// Complete records which events of a sent chunk wait for a retry and which are done.
+	Complete(events []*types.StorageEvent, result AmplitudeProcessorResult)
+	// Pending reports whether the event waits for a retry that holds back later events of its user.
+	// Such events can't be replaced, e.g. merged by CoalesceIdentifies, since they are tracked by pointer.
+	Pending(event *types.StorageEvent) bool
+}
+
+type EventOrderKeeperOptions struct {
+	Now func() time.Time
+}
+
+func NewEventOrderKeeper(options EventOrderKeeperOptions) EventOrderKeeper {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &eventOrderKeeper{
+		options: options,
+		pending: make(map[string]*pendingRetries),
+	}
+}
+
+// pendingRetries are events of a user waiting for a retry, with their position in the order of the user.
+type pendingRetries struct {
+	events  map[*types.StorageEvent]uint64
+	retryAt time.Time
+}
+
+type eventOrderKeeper struct {
+	options  EventOrderKeeperOptions
+	pending  map[string]*pendingRetries
+	sequence uint64
+	mu       sync.Mutex
+}
+
+// Hold releases pending events of a user in the order of their sequence, up to the first one missing in the chunk,
+// and the other events of the user only if every pending event is released.
+// Released pending events are put first among the events of their user, so a pending retry is never held
+// behind a newer event of the user, e.g. when storage returns new events before retried ones.
+func (k *eventOrderKeeper) Hold(events []*types.StorageEvent) ([]*types.StorageEvent, []*types.StorageEvent) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	inChunk := make(map[*types.StorageEvent]struct{}, len(events))
+	for _, event := range events {
+		inChunk[event] = struct{}{}
+	}
+
+	// releasedRetries are released pending events of a user, releasedUsers are users whose pending events are all released.
+	releasedRetries := make(map[string][]*types.StorageEvent)
+	releasedUsers := make(map[string]bool)
+	released := make(map[*types.StorageEvent]struct{})
+
+	for _, event := range events {
+		key := UserKey(event.Event)
+
+		pending, ok := k.pending[key]
+		if !ok {
+			continue
+		}
+
+		if _, ok := releasedUsers[key]; ok {
+			continue
+		}
+
+		retries, all := pending.release(inChunk)
+		for _, retry := range retries {
+			released[retry] = struct{}{}
+		}
+
+		releasedRetries[key] = retries
+		releasedUsers[key] = all
+	}
+
+	ready := make([]*types.StorageEvent, 0, len(events))
+	placedUsers := make(map[string]struct{})
+	now := k.options.Now()
+
+	var held []*types.StorageEvent
+
+	for _, event := range events {
+		key := UserKey(event.Event)
+
+		pending, ok := k.pending[key]
+		if !ok {
+			ready = append(ready, event)
+
+			continue
+		}
+
+		if _, ok := placedUsers[key]; !ok {
+			placedUsers[key] = struct{}{}
+			ready = append(ready, releasedRetries[key]...)
+		}
+
+		if _, ok := released[event]; ok {
+			continue
+		}
+
+		if _, retrying := pending.events[event]; !retrying && releasedUsers[key] {
+			ready = append(ready, event)
+
+			continue
+		}
+
+		event.RetryAt = pending.retryAt
+		if event.RetryAt.Before(now) {
+			event.RetryAt = now
+		}
+
+		held = append(held, event)
+	}
+
+	return ready, held
+}
+
+// release returns the pending events in the chunk in the order of their sequence, up to the first one missing,
+// and whether every pending event is in the chunk.
+func (p *pendingRetries) release(inChunk map[*types.StorageEvent]struct{}) ([]*types.StorageEvent, bool) {
+	events := make([]*types.StorageEvent, 0, len(p.events))
+	for event := range p.events {
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return p.events[events[i]] < p.events[events[j]]
+	})
+
+	for i, event := range events {
+		if _, ok := inChunk[event]; !ok {
+			return events[:i], false
+		}
+	}
+
+	return events, true
+}
+
+func (k *eventOrderKeeper) Complete(events []*types.StorageEvent, result AmplitudeProcessorResult) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	retried := make(map[*types.StorageEvent]struct{}, len(result.EventsForRetry))
+	for _, event := range result.EventsForRetry {
+		retried[event] = struct{}{}
+	}
+
+	for _, event := range events {
+		key := UserKey(event.Event)
+		pending := k.pending[key]
+
+		if _, ok := retried[event]; !ok {
+			if pending != nil {
+				delete(pending.events, event)
+
+				if len(pending.events) == 0 {
+					delete(k.pending, key)
+				}
+			}
+
+			continue
+		}
+
+		if pending == nil {
+			pending = &pendingRetries{events: make(map[*types.StorageEvent]uint64)}
+			k.pending[key] = pending
+		}
+
+		if _, ok := pending.events[event]; !ok {
+			k.sequence++
+			pending.events[event] = k.sequence
+		}
+
+		if event.RetryAt.After(pending.retryAt) {
+			pending.retryAt = event.RetryAt
+		}
+	}
+}
+
+// Pending reports whether the event waits for a retry that holds back later events of its user.
+func (k *eventOrderKeeper) Pending(event *types.StorageEvent) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	pending, ok := k.pending[UserKey(event.Event)]
+	if !ok {
+		return false
+	}
+
+	_, ok = pending.events[event]
+
+	return ok
+}
+
+// UserKey returns the user ID of the event, or its device ID if the user ID is empty.
+func UserKey(event *types.Event) string {
+	if event.EventOptions.UserID != "" {
+		return "user:" + event.EventOptions.UserID
+	}
+
+	if event.UserID != "" {
+		return "user:" + event.UserID
+	}
+
+	if event.EventOptions.DeviceID != "" {
+		return "device:" + event.EventOptions.DeviceID
+	}
+
+	return "device:" + event.DeviceID
+}
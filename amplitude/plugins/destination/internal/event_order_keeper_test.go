@@ -0,0 +1,165 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestEventOrderKeeper(t *testing.T) {
+	suite.Run(t, new(EventOrderKeeperSuite))
+}
+
+type EventOrderKeeperSuite struct {
+	suite.Suite
+}
+
+func (t *EventOrderKeeperSuite) TestHoldUntilRetryResolves() {
+	now := time.Now()
+	keeper := internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{
+		Now: func() time.Time { return now },
+	})
+
+	a1, a2, a3 := t.createEvent("a", "identify"), t.createEvent("a", "track-1"), t.createEvent("a", "track-2")
+	b1, b2 := t.createEvent("b", "track-1"), t.createEvent("b", "track-2")
+
+	require := t.Require()
+
+	ready, held := keeper.Hold([]*types.StorageEvent{a1, b1})
+	require.Equal([]*types.StorageEvent{a1, b1}, ready)
+	require.Empty(held)
+
+	a1.RetryAt = now.Add(time.Second * 30)
+	keeper.Complete(ready, internal.AmplitudeProcessorResult{
+		EventsForCallback: []*types.StorageEvent{b1},
+		EventsForRetry:    []*types.StorageEvent{a1},
+	})
+
+	ready, held = keeper.Hold([]*types.StorageEvent{a2, b2})
+	require.Equal([]*types.StorageEvent{b2}, ready)
+	require.Equal([]*types.StorageEvent{a2}, held)
+	require.Equal(a1.RetryAt, a2.RetryAt)
+
+	ready, held = keeper.Hold([]*types.StorageEvent{a1, a2})
+	require.Equal([]*types.StorageEvent{a1, a2}, ready)
+	require.Empty(held)
+
+	keeper.Complete(ready, internal.AmplitudeProcessorResult{
+		EventsForCallback: []*types.StorageEvent{a1, a2},
+	})
+
+	ready, held = keeper.Hold([]*types.StorageEvent{a3})
+	require.Equal([]*types.StorageEvent{a3}, ready)
+	require.Empty(held)
+}
+
+func (t *EventOrderKeeperSuite) TestHoldRetriesAfterThrottledEvent() {
+	now := time.Now()
+	keeper := internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{
+		Now: func() time.Time { return now },
+	})
+
+	a1, a2, a3 := t.createEvent("a", "identify"), t.createEvent("a", "track-1"), t.createEvent("a", "track-2")
+	chunk := []*types.StorageEvent{a1, a2}
+
+	// a1 is throttled and delayed, a2 is retried right away.
+	a1.RetryAt = now.Add(time.Second * 30)
+	keeper.Complete(chunk, internal.AmplitudeProcessorResult{
+		EventsForRetry: []*types.StorageEvent{a2, a1},
+	})
+
+	require := t.Require()
+
+	ready, held := keeper.Hold([]*types.StorageEvent{a2, a3})
+	require.Empty(ready)
+	require.Equal([]*types.StorageEvent{a2, a3}, held)
+	require.Equal(a1.RetryAt, a2.RetryAt)
+	require.Equal(a1.RetryAt, a3.RetryAt)
+
+	ready, held = keeper.Hold([]*types.StorageEvent{a1, a2, a3})
+	require.Equal([]*types.StorageEvent{a1, a2, a3}, ready)
+	require.Empty(held)
+}
+
+func (t *EventOrderKeeperSuite) TestHoldReleasesRetryBeforeNewerEvent() {
+	now := time.Now()
+	keeper := internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{
+		Now: func() time.Time { return now },
+	})
+
+	a1, a2 := t.createEvent("a", "identify"), t.createEvent("a", "track-1")
+	b1 := t.createEvent("b", "track-1")
+
+	a1.RetryAt = now.Add(-time.Second)
+	keeper.Complete([]*types.StorageEvent{a1}, internal.AmplitudeProcessorResult{
+		EventsForRetry: []*types.StorageEvent{a1},
+	})
+
+	// Storage returns new events before retried ones.
+	ready, held := keeper.Hold([]*types.StorageEvent{b1, a2, a1})
+
+	require := t.Require()
+	require.Equal([]*types.StorageEvent{b1, a1, a2}, ready)
+	require.Empty(held)
+}
+
+func (t *EventOrderKeeperSuite) TestHoldReleasesRetriesBySequence() {
+	now := time.Now()
+	keeper := internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{
+		Now: func() time.Time { return now },
+	})
+
+	a1, a2, a3, a4 := t.createEvent("a", "track-1"), t.createEvent("a", "track-2"), t.createEvent("a", "track-3"),
+		t.createEvent("a", "track-4")
+
+	keeper.Complete([]*types.StorageEvent{a1, a2, a3}, internal.AmplitudeProcessorResult{
+		EventsForRetry: []*types.StorageEvent{a1, a2, a3},
+	})
+
+	require := t.Require()
+
+	// a2 is missing, so a3 and the newer a4 wait for it.
+	ready, held := keeper.Hold([]*types.StorageEvent{a4, a3, a1})
+	require.Equal([]*types.StorageEvent{a1}, ready)
+	require.Equal([]*types.StorageEvent{a4, a3}, held)
+	require.Equal(now, a4.RetryAt)
+}
+
+func (t *EventOrderKeeperSuite) TestPending() {
+	keeper := internal.NewEventOrderKeeper(internal.EventOrderKeeperOptions{})
+
+	a1, a2 := t.createEvent("a", "identify"), t.createEvent("a", "track-1")
+
+	keeper.Complete([]*types.StorageEvent{a1, a2}, internal.AmplitudeProcessorResult{
+		EventsForRetry: []*types.StorageEvent{a1},
+	})
+
+	require := t.Require()
+	require.True(keeper.Pending(a1))
+	require.False(keeper.Pending(a2))
+
+	keeper.Complete([]*types.StorageEvent{a1}, internal.AmplitudeProcessorResult{})
+	require.False(keeper.Pending(a1))
+}
+
+func (t *EventOrderKeeperSuite) TestUserKey() {
+	require := t.Require()
+
+	require.Equal("user:u1", internal.UserKey(&types.Event{EventOptions: types.EventOptions{UserID: "u1"}, UserID: "u2"}))
+	require.Equal("user:u2", internal.UserKey(&types.Event{UserID: "u2", DeviceID: "d1"}))
+	require.Equal("device:d1", internal.UserKey(&types.Event{EventOptions: types.EventOptions{DeviceID: "d1"}}))
+	require.Equal("device:d2", internal.UserKey(&types.Event{DeviceID: "d2"}))
+}
+
+func (t *EventOrderKeeperSuite) createEvent(userID string, eventType string) *types.StorageEvent {
+	return &types.StorageEvent{
+		Event: &types.Event{
+			EventType:    eventType,
+			EventOptions: types.EventOptions{UserID: userID},
+		},
+	}
+}
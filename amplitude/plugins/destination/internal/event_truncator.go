@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// TruncationLimits are the limits properties of events are truncated to, a limit of 0 isn't enforced.
+type TruncationLimits struct {
+	MaxStringLength int
+	MaxPropertyKeys int
+	MaxArrayLength  int
+	MaxDepth        int
+}
+
+// Half returns limits of half the size, used to salvage an event that is still too large.
+func (l TruncationLimits) Half() TruncationLimits {
+	half := func(limit int) int {
+		if limit > 1 {
+			return limit / 2
+		}
+
+		return limit
+	}
+
+	return TruncationLimits{
+		MaxStringLength: half(l.MaxStringLength),
+		MaxPropertyKeys: half(l.MaxPropertyKeys),
+		MaxArrayLength:  half(l.MaxArrayLength),
+		MaxDepth:        l.MaxDepth,
+	}
+}
+
+// TruncateEvent truncates event, user and group properties of the event to the limits.
+// Truncated properties are replaced with copies, so values shared with other events are not changed.
+// It returns a description of every truncation, or nil if the event is within the limits.
+func TruncateEvent(event *types.Event, limits TruncationLimits) []string {
+	t := truncator{limits: limits}
+
+	event.EventProperties = t.truncateMap("event_properties", event.EventProperties, 1)
+	event.UserProperties = t.truncateIdentityProperties("user_properties", event.UserProperties)
+	event.GroupProperties = t.truncateIdentityProperties("group_properties", event.GroupProperties)
+
+	return t.truncations
+}
+
+type truncator struct {
+	limits      TruncationLimits
+	truncations []string
+}
+
+func (t *truncator) truncateIdentityProperties(
+	path string, properties map[types.IdentityOp]map[string]interface{},
+) map[types.IdentityOp]map[string]interface{} {
+	if properties == nil {
+		return nil
+	}
+
+	ops := make([]string, 0, len(properties))
+	for op := range properties {
+		ops = append(ops, string(op))
+	}
+
+	sort.Strings(ops)
+
+	truncated := make(map[types.IdentityOp]map[string]interface{}, len(properties))
+	for _, op := range ops {
+		truncated[types.IdentityOp(op)] = t.truncateMap(path+"."+op, properties[types.IdentityOp(op)], 1)
+	}
+
+	return truncated
+}
+
+func (t *truncator) truncateMap(path string, properties map[string]interface{}, depth int) map[string]interface{} {
+	if properties == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	if t.limits.MaxPropertyKeys > 0 && len(keys) > t.limits.MaxPropertyKeys {
+		t.add("%s: %d properties dropped over %d", path, len(keys)-t.limits.MaxPropertyKeys, t.limits.MaxPropertyKeys)
+		keys = keys[:t.limits.MaxPropertyKeys]
+	}
+
+	truncated := make(map[string]interface{}, len(keys))
+
+	for _, key := range keys {
+		if value, ok := t.truncateValue(path+"."+key, properties[key], depth); ok {
+			truncated[key] = value
+		}
+	}
+
+	return truncated
+}
+
+// truncateValue returns the truncated value, or false if the value is dropped.
+func (t *truncator) truncateValue(path string, value interface{}, depth int) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return t.truncateString(path, value), true
+	case map[string]interface{}:
+		if t.exceedsDepth(path, depth) {
+			return nil, false
+		}
+
+		return t.truncateMap(path, value, depth+1), true
+	case []interface{}:
+		if t.exceedsDepth(path, depth) {
+			return nil, false
+		}
+
+		items := t.truncateArray(path, value).([]interface{})
+		truncated := make([]interface{}, 0, len(items))
+
+		// Items dropped for depth are removed, not sent as null.
+		for i, item := range items {
+			if item, ok := t.truncateValue(fmt.Sprintf("%s[%d]", path, i), item, depth+1); ok {
+				truncated = append(truncated, item)
+			}
+		}
+
+		return truncated, true
+	case []map[string]interface{}:
+		if t.exceedsDepth(path, depth) {
+			return nil, false
+		}
+
+		items := t.truncateArray(path, value).([]map[string]interface{})
+		truncated := make([]map[string]interface{}, 0, len(items))
+
+		for i, item := range items {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if !t.exceedsDepth(itemPath, depth+1) {
+				truncated = append(truncated, t.truncateMap(itemPath, item, depth+2))
+			}
+		}
+
+		return truncated, true
+	case []string:
+		items := t.truncateArray(path, value).([]string)
+		truncated := make([]string, len(items))
+
+		for i, item := range items {
+			truncated[i] = t.truncateString(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+
+		return truncated, true
+	}
+
+	if isComposite(reflect.TypeOf(value)) {
+		return t.truncateComposite(path, value, depth)
+	}
+
+	if reflect.ValueOf(value).Kind() == reflect.Slice {
+		return t.truncateArray(path, value), true
+	}
+
+	return value, true
+}
+
+// truncateComposite truncates a struct, a map or a slice of them as the JSON it's sent as.
+// The value is kept as it is if it's within the limits.
+func (t *truncator) truncateComposite(path string, value interface{}, depth int) (interface{}, bool) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value, true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return value, true
+	}
+
+	truncations := len(t.truncations)
+
+	truncated, ok := t.truncateValue(path, decoded, depth)
+	if ok && len(t.truncations) == truncations {
+		return value, true
+	}
+
+	return truncated, ok
+}
+
+// isComposite reports whether values of the type are encoded as JSON objects, or arrays of objects or arrays.
+func isComposite(valueType reflect.Type) bool {
+	if valueType == nil {
+		return false
+	}
+
+	switch valueType.Kind() {
+	case reflect.Ptr:
+		return isComposite(valueType.Elem())
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		// Slices of basic values, including byte slices encoded as strings, are truncated as they are.
+		switch elem := valueType.Elem(); elem.Kind() {
+		case reflect.Interface, reflect.Slice, reflect.Array:
+			return true
+		default:
+			return isComposite(elem)
+		}
+	default:
+		return false
+	}
+}
+
+func (t *truncator) truncateString(path string, value string) string {
+	if t.limits.MaxStringLength <= 0 || utf8.RuneCountInString(value) <= t.limits.MaxStringLength {
+		return value
+	}
+
+	t.add("%s: string truncated to %d characters", path, t.limits.MaxStringLength)
+
+	return string([]rune(value)[:t.limits.MaxStringLength])
+}
+
+// truncateArray returns the first MaxArrayLength items of a slice.
+func (t *truncator) truncateArray(path string, value interface{}) interface{} {
+	slice := reflect.ValueOf(value)
+	if t.limits.MaxArrayLength <= 0 || slice.Len() <= t.limits.MaxArrayLength {
+		return value
+	}
+
+	t.add("%s: array truncated to %d items", path, t.limits.MaxArrayLength)
+
+	return slice.Slice(0, t.limits.MaxArrayLength).Interface()
+}
+
+func (t *truncator) exceedsDepth(path string, depth int) bool {
+	if t.limits.MaxDepth <= 0 || depth < t.limits.MaxDepth {
+		return false
+	}
+
+	t.add("%s: dropped, nested deeper than %d", path, t.limits.MaxDepth)
+
+	return true
+}
+
+func (t *truncator) add(format string, args ...interface{}) {
+	t.truncations = append(t.truncations, fmt.Sprintf(format, args...))
+}
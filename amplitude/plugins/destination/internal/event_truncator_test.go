@@ -0,0 +1,216 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestEventTruncator(t *testing.T) {
+	suite.Run(t, new(EventTruncatorSuite))
+}
+
+type EventTruncatorSuite struct {
+	suite.Suite
+}
+
+var truncationLimits = internal.TruncationLimits{
+	MaxStringLength: 5,
+	MaxPropertyKeys: 3,
+	MaxArrayLength:  2,
+	MaxDepth:        2,
+}
+
+func (t *EventTruncatorSuite) TestWithinLimits() {
+	event := &types.Event{
+		EventProperties: map[string]interface{}{
+			"string": "short",
+			"number": 42,
+			"nested": map[string]interface{}{"list": []string{"a", "b"}},
+		},
+	}
+
+	require := t.Require()
+	require.Nil(internal.TruncateEvent(event, truncationLimits))
+	require.Equal("short", event.EventProperties["string"])
+
+	require.Nil(internal.TruncateEvent(event, internal.TruncationLimits{}))
+}
+
+func (t *EventTruncatorSuite) TestTruncateEvent() {
+	nested := map[string]interface{}{
+		"text":  "long text",
+		"deep":  map[string]interface{}{"key": "value"},
+		"items": []interface{}{"abcdefg", 2, 3},
+	}
+
+	event := &types.Event{
+		EventProperties: map[string]interface{}{
+			"a":      "ünïcödé text",
+			"b":      []int{1, 2, 3},
+			"nested": nested,
+			"z":      "dropped",
+		},
+		UserProperties: map[types.IdentityOp]map[string]interface{}{
+			types.IdentityOpSet: {"name": strings.Repeat("x", 10)},
+		},
+	}
+
+	truncations := internal.TruncateEvent(event, truncationLimits)
+
+	require := t.Require()
+	require.Equal([]string{
+		"event_properties: 1 properties dropped over 3",
+		"event_properties.a: string truncated to 5 characters",
+		"event_properties.b: array truncated to 2 items",
+		"event_properties.nested.deep: dropped, nested deeper than 2",
+		"event_properties.nested.items: dropped, nested deeper than 2",
+		"event_properties.nested.text: string truncated to 5 characters",
+		"user_properties.$set.name: string truncated to 5 characters",
+	}, truncations)
+
+	require.Equal(map[string]interface{}{
+		"a":      "ünïcö",
+		"b":      []int{1, 2},
+		"nested": map[string]interface{}{"text": "long "},
+	}, event.EventProperties)
+	require.Equal("xxxxx", event.UserProperties[types.IdentityOpSet]["name"])
+
+	// Values shared with other events are not changed.
+	require.Equal("long text", nested["text"])
+	require.Len(nested, 3)
+}
+
+func (t *EventTruncatorSuite) TestTruncateArrays() {
+	event := &types.Event{
+		EventProperties: map[string]interface{}{
+			"items":   []interface{}{"abcdefg", map[string]interface{}{"key": "value"}, 3},
+			"strings": []string{"abcdefg"},
+		},
+	}
+
+	truncations := internal.TruncateEvent(event, truncationLimits)
+
+	require := t.Require()
+	require.Equal([]string{
+		"event_properties.items: array truncated to 2 items",
+		"event_properties.items[0]: string truncated to 5 characters",
+		"event_properties.items[1]: dropped, nested deeper than 2",
+		"event_properties.strings[0]: string truncated to 5 characters",
+	}, truncations)
+	require.Equal([]interface{}{"abcde"}, event.EventProperties["items"])
+	require.Equal([]string{"abcde"}, event.EventProperties["strings"])
+}
+
+func (t *EventTruncatorSuite) TestTruncateSliceOfMaps() {
+	items := []map[string]interface{}{
+		{"name": "abcdefg", "deep": map[string]interface{}{"key": "value"}},
+		{"name": "short"},
+		{"name": "dropped"},
+	}
+
+	event := &types.Event{
+		EventProperties: map[string]interface{}{
+			"items": items,
+			"nested": map[string]interface{}{
+				"items": []map[string]interface{}{{"key": "value"}},
+			},
+		},
+	}
+
+	truncations := internal.TruncateEvent(event, internal.TruncationLimits{
+		MaxStringLength: 5,
+		MaxArrayLength:  2,
+		MaxDepth:        3,
+	})
+
+	require := t.Require()
+	require.Equal([]string{
+		"event_properties.items: array truncated to 2 items",
+		"event_properties.items[0].deep: dropped, nested deeper than 3",
+		"event_properties.items[0].name: string truncated to 5 characters",
+		"event_properties.nested.items[0]: dropped, nested deeper than 3",
+	}, truncations)
+	require.Equal(map[string]interface{}{
+		"items":  []map[string]interface{}{{"name": "abcde"}, {"name": "short"}},
+		"nested": map[string]interface{}{"items": []map[string]interface{}{}},
+	}, event.EventProperties)
+
+	// Values shared with other events are not changed.
+	require.Equal("abcdefg", items[0]["name"])
+}
+
+func (t *EventTruncatorSuite) TestTruncateStructs() {
+	type item struct {
+		Name  string            `json:"name"`
+		Tags  []string          `json:"tags"`
+		Price int64             `json:"price"`
+		Attrs map[string]string `json:"attrs,omitempty"`
+	}
+
+	short := item{Name: "short", Price: 1}
+
+	event := &types.Event{
+		EventProperties: map[string]interface{}{
+			"item":  item{Name: "abcdefg", Tags: []string{"a", "b", "c"}, Price: 9007199254740993},
+			"items": []*item{{Name: "abcdefg"}, {Name: "b"}, {Name: "c"}},
+			"attrs": map[string]string{"key": "abcdefg"},
+			"short": short,
+		},
+	}
+
+	truncations := internal.TruncateEvent(event, internal.TruncationLimits{
+		MaxStringLength: 5,
+		MaxArrayLength:  2,
+		MaxDepth:        3,
+	})
+
+	require := t.Require()
+	require.Equal([]string{
+		"event_properties.attrs.key: string truncated to 5 characters",
+		"event_properties.item.name: string truncated to 5 characters",
+		"event_properties.item.tags: array truncated to 2 items",
+		"event_properties.items: array truncated to 2 items",
+		"event_properties.items[0].name: string truncated to 5 characters",
+	}, truncations)
+	require.Equal(map[string]interface{}{
+		"attrs": map[string]interface{}{"key": "abcde"},
+		"item": map[string]interface{}{
+			"name":  "abcde",
+			"tags":  []interface{}{"a", "b"},
+			"price": json.Number("9007199254740993"),
+		},
+		"items": []interface{}{
+			map[string]interface{}{"name": "abcde", "tags": nil, "price": json.Number("0")},
+			map[string]interface{}{"name": "b", "tags": nil, "price": json.Number("0")},
+		},
+		"short": short,
+	}, event.EventProperties)
+}
+
+func (t *EventTruncatorSuite) TestHalf() {
+	require := t.Require()
+	require.Equal(internal.TruncationLimits{
+		MaxStringLength: 2,
+		MaxPropertyKeys: 1,
+		MaxArrayLength:  1,
+		MaxDepth:        2,
+	}, truncationLimits.Half())
+	require.Equal(internal.TruncationLimits{}, internal.TruncationLimits{}.Half())
+
+	properties := make(map[string]interface{})
+	for i := 0; i < 4; i++ {
+		properties[fmt.Sprintf("key-%d", i)] = i
+	}
+
+	event := &types.Event{EventProperties: properties}
+	require.NotEmpty(internal.TruncateEvent(event, truncationLimits.Half()))
+	require.Equal(map[string]interface{}{"key-0": 0}, event.EventProperties)
+	require.Nil(internal.TruncateEvent(event, truncationLimits.Half()))
+}
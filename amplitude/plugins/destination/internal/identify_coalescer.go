@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// CoalesceIdentifies merges identify events of a user that follow each other in the chunk,
+// with no other event of the user in between, into one identify event at the position of the last one.
+// Operations are combined so the user profile ends up the same, e.g. $set then $add of a number is a $set of the sum.
+// Identify events whose operations can't be combined, or events waiting for a retry, are kept as they are.
+// Events for which pending returns true are kept as well, pending may be nil.
+// It returns the events and the number of events merged into others.
+func CoalesceIdentifies(events []*types.StorageEvent, pending func(*types.StorageEvent) bool) ([]*types.StorageEvent, int) {
+	result := make([]*types.StorageEvent, 0, len(events))
+	coalesced := 0
+
+	// lastIdentify is the index in result of the identify event of a user that the next one may be merged into.
+	lastIdentify := make(map[string]int)
+
+	for _, event := range events {
+		key := UserKey(event.Event)
+
+		if !isCoalescable(event) || (pending != nil && pending(event)) {
+			delete(lastIdentify, key)
+			result = append(result, event)
+
+			continue
+		}
+
+		if i, ok := lastIdentify[key]; ok {
+			if merged := mergeIdentifies(result[i], event); merged != nil {
+				result[i] = nil
+				event = merged
+				coalesced++
+			}
+		}
+
+		lastIdentify[key] = len(result)
+		result = append(result, event)
+	}
+
+	if coalesced == 0 {
+		return events, 0
+	}
+
+	kept := result[:0]
+
+	for _, event := range result {
+		if event != nil {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept, coalesced
+}
+
+func isCoalescable(event *types.StorageEvent) bool {
+	return event.EventType == constants.IdentifyEventType && event.RetryCount == 0 && event.RetryAt.IsZero() &&
+		len(event.EventProperties) == 0 && len(event.Groups) == 0 && len(event.GroupProperties) == 0
+}
+
+// mergeIdentifies returns an identify event with the effect of first followed by second, or nil if there is none.
+func mergeIdentifies(first *types.StorageEvent, second *types.StorageEvent) *types.StorageEvent {
+	if first.EventOptions.UserID != second.EventOptions.UserID || first.EventOptions.DeviceID != second.EventOptions.DeviceID ||
+		first.Event.UserID != second.Event.UserID || first.Event.DeviceID != second.Event.DeviceID {
+		return nil
+	}
+
+	properties, ok := mergeUserProperties(first.UserProperties, second.UserProperties)
+	if !ok {
+		return nil
+	}
+
+	event := second.Event.Clone()
+	event.UserProperties = properties
+
+	merged := &types.StorageEvent{
+		Event:           &event,
+		RetryAt:         second.RetryAt,
+		CoalescedEvents: append(coalescedEvents(first), coalescedEvents(second)...),
+	}
+	merged.Truncations = append(merged.Truncations, first.Truncations...)
+	merged.Truncations = append(merged.Truncations, second.Truncations...)
+
+	return merged
+}
+
+func coalescedEvents(event *types.StorageEvent) []*types.Event {
+	if len(event.CoalescedEvents) > 0 {
+		return event.CoalescedEvents
+	}
+
+	return []*types.Event{event.Event}
+}
+
+// userPropertyOperation is the operation on a property within an identify event.
+type userPropertyOperation struct {
+	op    types.IdentityOp
+	value interface{}
+}
+
+func mergeUserProperties(
+	first map[types.IdentityOp]map[string]interface{}, second map[types.IdentityOp]map[string]interface{},
+) (map[types.IdentityOp]map[string]interface{}, bool) {
+	// $clearAll discards the effect of the first event, but operations after it can't be added to it.
+	if _, ok := second[types.IdentityOpClearAll]; ok {
+		return second, true
+	}
+
+	if _, ok := first[types.IdentityOpClearAll]; ok {
+		return nil, false
+	}
+
+	operations, ok := propertyOperations(first)
+	if !ok {
+		return nil, false
+	}
+
+	secondOperations, ok := propertyOperations(second)
+	if !ok {
+		return nil, false
+	}
+
+	for property, next := range secondOperations {
+		previous, ok := operations[property]
+		if !ok {
+			operations[property] = next
+
+			continue
+		}
+
+		combined, ok := combineOperations(previous, next)
+		if !ok {
+			return nil, false
+		}
+
+		operations[property] = combined
+	}
+
+	properties := make(map[types.IdentityOp]map[string]interface{})
+
+	for property, operation := range operations {
+		if properties[operation.op] == nil {
+			properties[operation.op] = make(map[string]interface{})
+		}
+
+		properties[operation.op][property] = operation.value
+	}
+
+	return properties, true
+}
+
+// propertyOperations returns the operation of every property, or false if a property has several operations.
+func propertyOperations(properties map[types.IdentityOp]map[string]interface{}) (map[string]userPropertyOperation, bool) {
+	operations := make(map[string]userPropertyOperation)
+
+	for op, values := range properties {
+		for property, value := range values {
+			if _, ok := operations[property]; ok {
+				return nil, false
+			}
+
+			operations[property] = userPropertyOperation{op: op, value: value}
+		}
+	}
+
+	return operations, true
+}
+
+// combineOperations returns one operation with the effect of previous followed by next on the same property.
+func combineOperations(previous userPropertyOperation, next userPropertyOperation) (userPropertyOperation, bool) {
+	switch next.op {
+	case types.IdentityOpSet, types.IdentityOpUnset:
+		return next, true
+	case types.IdentityOpSetOnce:
+		// $setOnce only has an effect if the property has no value after the previous operation.
+		if previous.op == types.IdentityOpUnset {
+			return userPropertyOperation{op: types.IdentityOpSet, value: next.value}, true
+		}
+
+		return previous, true
+	case types.IdentityOpAdd:
+		switch previous.op {
+		case types.IdentityOpUnset:
+			// $add initializes a property without a value to 0.
+			if _, ok := floatValue(reflect.ValueOf(next.value)); ok {
+				return userPropertyOperation{op: types.IdentityOpSet, value: next.value}, true
+			}
+		case types.IdentityOpSet, types.IdentityOpAdd:
+			if sum, ok := addNumbers(previous.value, next.value); ok {
+				return userPropertyOperation{op: previous.op, value: sum}, true
+			}
+		}
+	}
+
+	return userPropertyOperation{}, false
+}
+
+// addNumbers adds two numbers, keeping integers as int64 unless the sum overflows.
+func addNumbers(a interface{}, b interface{}) (interface{}, bool) {
+	aValue, bValue := reflect.ValueOf(a), reflect.ValueOf(b)
+
+	aInt, aIsInt := integerValue(aValue)
+	bInt, bIsInt := integerValue(bValue)
+
+	if aIsInt && bIsInt {
+		sum := aInt + bInt
+		if (sum > aInt) == (bInt > 0) {
+			return sum, true
+		}
+	}
+
+	aFloat, aIsNumber := floatValue(aValue)
+	bFloat, bIsNumber := floatValue(bValue)
+
+	if !aIsNumber || !bIsNumber || math.IsNaN(aFloat+bFloat) {
+		return nil, false
+	}
+
+	return aFloat + bFloat, true
+}
+
+func integerValue(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() <= math.MaxInt64 {
+			return int64(v.Uint()), true
+		}
+	}
+
+	return 0, false
+}
+
+func floatValue(v reflect.Value) (float64, bool) {
+	if i, ok := integerValue(v); ok {
+		return float64(i), true
+	}
+
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,195 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestIdentifyCoalescer(t *testing.T) {
+	suite.Run(t, new(IdentifyCoalescerSuite))
+}
+
+type IdentifyCoalescerSuite struct {
+	suite.Suite
+}
+
+type userProperties = map[types.IdentityOp]map[string]interface{}
+
+func (t *IdentifyCoalescerSuite) TestCombineOperations() {
+	tests := []struct {
+		name     string
+		first    userProperties
+		second   userProperties
+		expected userProperties
+	}{
+		{
+			name:     "different properties",
+			first:    userProperties{types.IdentityOpSet: {"a": 1}, types.IdentityOpAppend: {"b": "x"}},
+			second:   userProperties{types.IdentityOpSet: {"c": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": 1, "c": 2}, types.IdentityOpAppend: {"b": "x"}},
+		},
+		{
+			name:     "set overrides",
+			first:    userProperties{types.IdentityOpAppend: {"a": "x"}},
+			second:   userProperties{types.IdentityOpSet: {"a": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": 2}},
+		},
+		{
+			name:     "unset overrides",
+			first:    userProperties{types.IdentityOpSet: {"a": 1}},
+			second:   userProperties{types.IdentityOpUnset: {"a": types.UnsetValue}},
+			expected: userProperties{types.IdentityOpUnset: {"a": types.UnsetValue}},
+		},
+		{
+			name:     "setOnce after set",
+			first:    userProperties{types.IdentityOpSet: {"a": 1}},
+			second:   userProperties{types.IdentityOpSetOnce: {"a": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": 1}},
+		},
+		{
+			name:     "setOnce after unset",
+			first:    userProperties{types.IdentityOpUnset: {"a": types.UnsetValue}},
+			second:   userProperties{types.IdentityOpSetOnce: {"a": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": 2}},
+		},
+		{
+			name:     "add after set",
+			first:    userProperties{types.IdentityOpSet: {"a": 1}},
+			second:   userProperties{types.IdentityOpAdd: {"a": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": int64(3)}},
+		},
+		{
+			name:     "add after add",
+			first:    userProperties{types.IdentityOpAdd: {"a": 1}},
+			second:   userProperties{types.IdentityOpAdd: {"a": 0.5}},
+			expected: userProperties{types.IdentityOpAdd: {"a": 1.5}},
+		},
+		{
+			name:     "add after unset",
+			first:    userProperties{types.IdentityOpUnset: {"a": types.UnsetValue}},
+			second:   userProperties{types.IdentityOpAdd: {"a": 2}},
+			expected: userProperties{types.IdentityOpSet: {"a": 2}},
+		},
+		{
+			name:     "clearAll",
+			first:    userProperties{types.IdentityOpSet: {"a": 1}},
+			second:   userProperties{types.IdentityOpClearAll: {types.UnsetValue: nil}},
+			expected: userProperties{types.IdentityOpClearAll: {types.UnsetValue: nil}},
+		},
+		{
+			name:   "add after set of a string",
+			first:  userProperties{types.IdentityOpSet: {"a": "x"}},
+			second: userProperties{types.IdentityOpAdd: {"a": 2}},
+		},
+		{
+			name:   "append after append",
+			first:  userProperties{types.IdentityOpAppend: {"a": "x"}},
+			second: userProperties{types.IdentityOpAppend: {"a": "y"}},
+		},
+		{
+			name:   "set after clearAll",
+			first:  userProperties{types.IdentityOpClearAll: {types.UnsetValue: nil}},
+			second: userProperties{types.IdentityOpSet: {"a": 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func() {
+			first := t.createIdentify("user-1", "insert-1", tt.first)
+			second := t.createIdentify("user-1", "insert-2", tt.second)
+
+			events, coalesced := internal.CoalesceIdentifies([]*types.StorageEvent{first, second}, nil)
+
+			require := t.Require()
+
+			if tt.expected == nil {
+				require.Zero(coalesced)
+				require.Equal([]*types.StorageEvent{first, second}, events)
+
+				return
+			}
+
+			require.Equal(1, coalesced)
+			require.Len(events, 1)
+			require.Equal(tt.expected, events[0].UserProperties)
+			require.Equal("insert-2", events[0].InsertID)
+			require.Equal([]*types.Event{first.Event, second.Event}, events[0].CoalescedEvents)
+		})
+	}
+}
+
+func (t *IdentifyCoalescerSuite) TestCoalesceIdentifies() {
+	set := func(property string) userProperties {
+		return userProperties{types.IdentityOpSet: {property: 1}}
+	}
+
+	a1 := t.createIdentify("user-a", "a1", set("p1"))
+	b1 := t.createIdentify("user-b", "b1", set("p1"))
+	a2 := t.createIdentify("user-a", "a2", set("p2"))
+	trackA := &types.StorageEvent{Event: &types.Event{EventType: "track", EventOptions: types.EventOptions{UserID: "user-a"}}}
+	a3 := t.createIdentify("user-a", "a3", set("p3"))
+	retried := t.createIdentify("user-a", "a4", set("p4"))
+	retried.RetryCount = 1
+	b2 := t.createIdentify("user-b", "b2", set("p2"))
+	a5 := t.createIdentify("user-a", "a5", set("p5"))
+
+	events, coalesced := internal.CoalesceIdentifies([]*types.StorageEvent{a1, b1, a2, trackA, a3, retried, b2, a5}, nil)
+
+	require := t.Require()
+	require.Equal(2, coalesced)
+
+	var insertIDs []string
+	for _, event := range events {
+		insertIDs = append(insertIDs, event.InsertID)
+	}
+
+	require.Equal([]string{"a2", "", "a3", "a4", "b2", "a5"}, insertIDs)
+	require.Equal(userProperties{types.IdentityOpSet: {"p1": 1, "p2": 1}}, events[0].UserProperties)
+	require.Equal(userProperties{types.IdentityOpSet: {"p1": 1, "p2": 1}}, events[4].UserProperties)
+	require.Equal([]*types.Event{b1.Event, b2.Event}, events[4].CoalescedEvents)
+
+	// Merged events are merged again with their CoalescedEvents.
+	events, coalesced = internal.CoalesceIdentifies([]*types.StorageEvent{events[0], t.createIdentify("user-a", "a6", set("p6"))}, nil)
+	require.Equal(1, coalesced)
+	require.Equal([]*types.Event{a1.Event, a2.Event, events[0].CoalescedEvents[2]}, events[0].CoalescedEvents)
+}
+
+func (t *IdentifyCoalescerSuite) TestCoalesceIdentifies_PendingRetries() {
+	set := func(property string) userProperties {
+		return userProperties{types.IdentityOpSet: {property: 1}}
+	}
+
+	// A 400 or 429 response retries an event without incrementing RetryCount.
+	throttled := t.createIdentify("user-a", "a1", set("p1"))
+	throttled.RetryAt = time.Now()
+	a2 := t.createIdentify("user-a", "a2", set("p2"))
+
+	events, coalesced := internal.CoalesceIdentifies([]*types.StorageEvent{throttled, a2}, nil)
+
+	require := t.Require()
+	require.Zero(coalesced)
+	require.Equal([]*types.StorageEvent{throttled, a2}, events)
+
+	pending := t.createIdentify("user-b", "b1", set("p1"))
+	b2 := t.createIdentify("user-b", "b2", set("p2"))
+
+	events, coalesced = internal.CoalesceIdentifies([]*types.StorageEvent{pending, b2}, func(event *types.StorageEvent) bool {
+		return event == pending
+	})
+	require.Zero(coalesced)
+	require.Equal([]*types.StorageEvent{pending, b2}, events)
+}
+
+func (t *IdentifyCoalescerSuite) createIdentify(userID string, insertID string, properties userProperties) *types.StorageEvent {
+	return &types.StorageEvent{Event: &types.Event{
+		EventType:      constants.IdentifyEventType,
+		EventOptions:   types.EventOptions{UserID: userID, InsertID: insertID},
+		UserProperties: properties,
+	}}
+}
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// payloadEnvelopeBytes is an upper bound of the payload bytes besides the API key and events.
+const payloadEnvelopeBytes = 128
+
+// EventSize returns the number of bytes the event adds to a payload.
+func EventSize(event *types.Event) int {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		// Encoding fails in Send as well, the event is reported there.
+		return 0
+	}
+
+	return len(eventBytes) + 1
+}
+
+// PayloadEnvelopeSize returns the number of bytes of a payload without events.
+func PayloadEnvelopeSize(apiKey string) int {
+	return len(apiKey) + payloadEnvelopeBytes
+}
@@ -0,0 +1,45 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/plugins/destination/internal"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestPayloadSize(t *testing.T) {
+	suite.Run(t, new(PayloadSizeSuite))
+}
+
+type PayloadSizeSuite struct {
+	suite.Suite
+}
+
+func (t *PayloadSizeSuite) TestPayloadSize() {
+	events := []*types.Event{
+		{EventType: "event-A", EventOptions: types.EventOptions{UserID: "user-1"}},
+		{EventType: "event-B", EventOptions: types.EventOptions{DeviceID: "device-1"}, EventProperties: map[string]interface{}{"k": "v"}},
+	}
+
+	payload := internal.AmplitudePayload{
+		APIKey:  "my-api-key",
+		Events:  events,
+		Options: &internal.AmplitudePayloadOptions{MinIDLength: 5},
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+
+	require := t.Require()
+	require.NoError(err)
+
+	size := internal.PayloadEnvelopeSize(payload.APIKey)
+	for _, event := range events {
+		size += internal.EventSize(event)
+	}
+
+	require.GreaterOrEqual(size, len(payloadBytes))
+	require.Less(size-len(payloadBytes), 128)
+}
@@ -0,0 +1,701 @@
+package storages
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amplitude/analytics-go/amplitude/loggers"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+const (
+	defaultMaxSegmentSize = 8 << 20
+
+	segmentFilePrefix = "segment-"
+	segmentFileSuffix = ".log"
+)
+
+type FileEventStorageOptions struct {
+	// Directory is where segment files are kept. It is created if it doesn't exist.
+	Directory string
+
+	// MaxSegmentSize is the size in bytes after which the active segment is compacted
+	// into a new segment holding only the events still in storage.
+	MaxSegmentSize int64
+
+	// SyncWrites calls fsync after every write.
+	// Without it events survive process crashes, but not necessarily OS crashes.
+	SyncWrites bool
+
+	Logger types.Logger
+}
+
+// NewFileEventStorage creates an EventStorage that keeps events in an append-only segment log,
+// so events waiting to be sent survive process restarts.
+// Events left in the directory by a previous process are loaded before it returns.
+// The storage implements types.LeasingEventStorage: leased events stay in the log until acknowledged.
+func NewFileEventStorage(options FileEventStorageOptions) (types.EventStorage, error) {
+	if options.Directory == "" {
+		return nil, fmt.Errorf("file event storage: directory is required")
+	}
+
+	if options.MaxSegmentSize <= 0 {
+		options.MaxSegmentSize = defaultMaxSegmentSize
+	}
+
+	if options.Logger == nil {
+		options.Logger = loggers.NewDefaultLogger()
+	}
+
+	if err := os.MkdirAll(options.Directory, 0o755); err != nil {
+		return nil, fmt.Errorf("file event storage: can't create directory: %w", err)
+	}
+
+	s := &fileEventStorage{
+		options: options,
+		memory:  &inMemoryEventStorage{},
+		ids:     make(map[*types.StorageEvent]uint64),
+		leases:  make(map[uint64]*types.EventLease),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type fileEventStorage struct {
+	options FileEventStorageOptions
+	memory  *inMemoryEventStorage
+	ids     map[*types.StorageEvent]uint64
+	nextID  uint64
+
+	leases      map[uint64]*types.EventLease
+	nextLeaseID uint64
+
+	segment      *os.File
+	segmentIndex uint64
+
+	// appendedSize is the size of the records written after the snapshot of the active segment.
+	appendedSize int64
+	snapshotSize int64
+
+	mu sync.Mutex
+}
+
+type fileRecordOp string
+
+const (
+	fileRecordOpPush     fileRecordOp = "push"
+	fileRecordOpReturn   fileRecordOp = "return"
+	fileRecordOpRemove   fileRecordOp = "remove"
+	fileRecordOpSnapshot fileRecordOp = "snapshot"
+)
+
+type fileRecord struct {
+	Op     fileRecordOp       `json:"op"`
+	Events []fileStorageEvent `json:"events,omitempty"`
+	IDs    []uint64           `json:"ids,omitempty"`
+}
+
+type fileStorageEvent struct {
+	ID            uint64        `json:"id"`
+	Event         *types.Event  `json:"event"`
+	UserID        string        `json:"user_id,omitempty"`
+	DeviceID      string        `json:"device_id,omitempty"`
+	RetryAt       time.Time     `json:"retry_at"`
+	RetryCount    int           `json:"retry_count,omitempty"`
+	RetryInterval time.Duration `json:"retry_interval,omitempty"`
+	Truncations   []string      `json:"truncations,omitempty"`
+}
+
+func (s *fileEventStorage) PushNew(event *types.StorageEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.PushNew(event)
+	s.write(fileRecord{Op: fileRecordOpPush, Events: s.toFileEvents(event)})
+}
+
+func (s *fileEventStorage) ReturnBack(events ...*types.StorageEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.memory.ReturnBack(events...)
+	s.write(fileRecord{Op: fileRecordOpReturn, Events: s.toFileEvents(events...)})
+}
+
+// Pull returns a chunk of events and removes them from the log.
+func (s *fileEventStorage) Pull(count int, before time.Time) []*types.StorageEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.memory.Pull(count, before)
+	if len(events) == 0 {
+		return events
+	}
+
+	ids := make([]uint64, 0, len(events))
+
+	for _, event := range events {
+		if id, ok := s.ids[event]; ok {
+			ids = append(ids, id)
+			delete(s.ids, event)
+		}
+	}
+
+	if len(ids) > 0 {
+		s.write(fileRecord{Op: fileRecordOpRemove, IDs: ids})
+	}
+
+	return events
+}
+
+func (s *fileEventStorage) Count(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.releaseExpiredLeases(time.Now())
+
+	return s.memory.Count(before)
+}
+
+// Lease returns a chunk of events without removing them from the log.
+func (s *fileEventStorage) Lease(count int, before time.Time, timeout time.Duration) *types.EventLease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.releaseExpiredLeases(now)
+
+	events := s.memory.Pull(count, before)
+	if len(events) == 0 {
+		return &types.EventLease{}
+	}
+
+	s.nextLeaseID++
+	lease := &types.EventLease{
+		ID:        s.nextLeaseID,
+		Events:    events,
+		ExpiresAt: now.Add(timeout),
+	}
+	s.leases[lease.ID] = &types.EventLease{
+		ID:        lease.ID,
+		Events:    append([]*types.StorageEvent(nil), events...),
+		ExpiresAt: lease.ExpiresAt,
+	}
+
+	return lease
+}
+
+func (s *fileEventStorage) Ack(lease *types.EventLease, events ...*types.StorageEvent) {
+	if lease == nil || len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leased, unknown := s.takeLeased(lease.ID, events)
+
+	if len(unknown) > 0 {
+		// The lease has expired and its events were made available again, but they have been delivered.
+		s.options.Logger.Warnf("File event storage, lease %d has expired before acknowledgement", lease.ID)
+		leased = append(leased, s.takeAvailable(unknown)...)
+	}
+
+	ids := make([]uint64, 0, len(leased))
+
+	for _, event := range leased {
+		if id, ok := s.ids[event]; ok {
+			ids = append(ids, id)
+			delete(s.ids, event)
+		}
+	}
+
+	if len(ids) > 0 {
+		s.write(fileRecord{Op: fileRecordOpRemove, IDs: ids})
+	}
+}
+
+func (s *fileEventStorage) Nack(lease *types.EventLease, events ...*types.StorageEvent) {
+	if lease == nil || len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	leased, unknown := s.takeLeased(lease.ID, events)
+
+	if len(unknown) > 0 {
+		s.options.Logger.Warnf("File event storage, lease %d has expired, %d events are already available again", lease.ID, len(unknown))
+	}
+
+	if len(leased) == 0 {
+		return
+	}
+
+	s.memory.ReturnBack(leased...)
+	s.write(fileRecord{Op: fileRecordOpReturn, Events: s.toFileEvents(leased...)})
+}
+
+// takeLeased removes events from the lease, returning the ones that were leased and the unknown ones.
+func (s *fileEventStorage) takeLeased(leaseID uint64, events []*types.StorageEvent) ([]*types.StorageEvent, []*types.StorageEvent) {
+	lease, ok := s.leases[leaseID]
+	if !ok {
+		return nil, events
+	}
+
+	requested := make(map[*types.StorageEvent]struct{}, len(events))
+	for _, event := range events {
+		requested[event] = struct{}{}
+	}
+
+	leased := make([]*types.StorageEvent, 0, len(events))
+	remaining := lease.Events[:0]
+
+	for _, event := range lease.Events {
+		if _, ok := requested[event]; ok {
+			leased = append(leased, event)
+			delete(requested, event)
+		} else {
+			remaining = append(remaining, event)
+		}
+	}
+
+	lease.Events = remaining
+	if len(lease.Events) == 0 {
+		delete(s.leases, leaseID)
+	}
+
+	var unknown []*types.StorageEvent
+
+	for _, event := range events {
+		if _, ok := requested[event]; ok {
+			unknown = append(unknown, event)
+		}
+	}
+
+	return leased, unknown
+}
+
+// takeAvailable removes events that are available for pulling, skipping events leased again.
+func (s *fileEventStorage) takeAvailable(events []*types.StorageEvent) []*types.StorageEvent {
+	leased := make(map[*types.StorageEvent]struct{})
+
+	for _, lease := range s.leases {
+		for _, event := range lease.Events {
+			leased[event] = struct{}{}
+		}
+	}
+
+	requested := make(map[*types.StorageEvent]struct{}, len(events))
+	available := make([]*types.StorageEvent, 0, len(events))
+
+	for _, event := range events {
+		if _, ok := leased[event]; !ok {
+			requested[event] = struct{}{}
+			available = append(available, event)
+		}
+	}
+
+	s.memory.remove(func(event *types.StorageEvent) bool {
+		_, ok := requested[event]
+
+		return ok
+	})
+
+	return available
+}
+
+func (s *fileEventStorage) releaseExpiredLeases(now time.Time) {
+	var expiredIDs []uint64
+
+	for id, lease := range s.leases {
+		if !now.Before(lease.ExpiresAt) {
+			expiredIDs = append(expiredIDs, id)
+		}
+	}
+
+	sort.Slice(expiredIDs, func(i, j int) bool {
+		return expiredIDs[i] > expiredIDs[j]
+	})
+
+	for _, id := range expiredIDs {
+		s.options.Logger.Warnf("File event storage, lease %d has expired, returning %d events", id, len(s.leases[id].Events))
+		s.memory.ReturnBack(s.leases[id].Events...)
+		delete(s.leases, id)
+	}
+}
+
+// leasedEvents returns events of active leases, oldest lease first.
+func (s *fileEventStorage) leasedEvents() []*types.StorageEvent {
+	leaseIDs := make([]uint64, 0, len(s.leases))
+	for id := range s.leases {
+		leaseIDs = append(leaseIDs, id)
+	}
+
+	sort.Slice(leaseIDs, func(i, j int) bool {
+		return leaseIDs[i] < leaseIDs[j]
+	})
+
+	var events []*types.StorageEvent
+	for _, id := range leaseIDs {
+		events = append(events, s.leases[id].Events...)
+	}
+
+	return events
+}
+
+// Close closes the active segment file. Events stay on disk and are loaded by the next NewFileEventStorage.
+func (s *fileEventStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.segment == nil {
+		return nil
+	}
+
+	err := s.segment.Close()
+	s.segment = nil
+
+	return err
+}
+
+func (s *fileEventStorage) toFileEvents(events ...*types.StorageEvent) []fileStorageEvent {
+	fileEvents := make([]fileStorageEvent, len(events))
+
+	for i, event := range events {
+		id, ok := s.ids[event]
+		if !ok {
+			s.nextID++
+			id = s.nextID
+			s.ids[event] = id
+		}
+
+		fileEvents[i] = fileStorageEvent{
+			ID:            id,
+			Event:         event.Event,
+			RetryAt:       event.RetryAt,
+			RetryCount:    event.RetryCount,
+			RetryInterval: event.RetryInterval,
+			Truncations:   event.Truncations,
+		}
+
+		if event.Event != nil {
+			fileEvents[i].UserID = event.Event.UserID
+			fileEvents[i].DeviceID = event.Event.DeviceID
+		}
+	}
+
+	return fileEvents
+}
+
+func (s *fileEventStorage) write(record fileRecord) {
+	if s.segment == nil {
+		s.options.Logger.Errorf("File event storage is closed, %s record is not persisted", record.Op)
+
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		s.options.Logger.Errorf("File event storage, can't encode %s record: %s", record.Op, err)
+
+		return
+	}
+
+	data = append(data, '\n')
+
+	n, err := s.segment.Write(data)
+	s.appendedSize += int64(n)
+
+	if err != nil {
+		s.options.Logger.Errorf("File event storage, can't write %s record: %s", record.Op, err)
+
+		return
+	}
+
+	if s.options.SyncWrites {
+		if err := s.segment.Sync(); err != nil {
+			s.options.Logger.Errorf("File event storage, can't sync segment: %s", err)
+		}
+	}
+
+	// A backlog larger than MaxSegmentSize is only compacted again once as many bytes are appended,
+	// instead of rewriting the snapshot on every write.
+	if s.appendedSize > s.options.MaxSegmentSize && s.appendedSize > s.snapshotSize {
+		if err := s.compact(); err != nil {
+			s.options.Logger.Errorf("File event storage, compaction failed: %s", err)
+		}
+	}
+}
+
+func (s *fileEventStorage) load() error {
+	segmentIndexes, err := s.segmentIndexes()
+	if err != nil {
+		return err
+	}
+
+	events := make(map[uint64]*types.StorageEvent)
+
+	for _, segmentIndex := range segmentIndexes {
+		if err := s.replaySegment(segmentIndex, events); err != nil {
+			return err
+		}
+
+		s.segmentIndex = segmentIndex
+	}
+
+	for _, id := range s.ids {
+		if id > s.nextID {
+			s.nextID = id
+		}
+	}
+
+	return s.compact()
+}
+
+func (s *fileEventStorage) replaySegment(segmentIndex uint64, events map[uint64]*types.StorageEvent) error {
+	file, err := os.Open(s.segmentPath(segmentIndex))
+	if err != nil {
+		return fmt.Errorf("file event storage: can't open segment: %w", err)
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.replayLine(segmentIndex, lineNumber, line, events)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("file event storage: can't read segment: %w", err)
+		}
+	}
+}
+
+func (s *fileEventStorage) replayLine(segmentIndex uint64, lineNumber int, line []byte, events map[uint64]*types.StorageEvent) {
+	var record fileRecord
+
+	// Numbers are decoded as float64, the same as properties of events decoded from JSON by the caller.
+	if err := json.Unmarshal(line, &record); err != nil {
+		// A partially written record is expected at the end of a segment after a crash.
+		s.options.Logger.Warnf("File event storage, skipping corrupted record %d in segment %d: %s", lineNumber, segmentIndex, err)
+
+		return
+	}
+
+	switch record.Op {
+	case fileRecordOpPush:
+		s.memory.push(false, s.fromFileEvents(record.Events, events)...)
+	case fileRecordOpReturn:
+		s.removeIDs(fileEventIDs(record.Events), events)
+		s.memory.push(true, s.fromFileEvents(record.Events, events)...)
+	case fileRecordOpRemove:
+		s.removeIDs(record.IDs, events)
+	case fileRecordOpSnapshot:
+		s.memory = &inMemoryEventStorage{}
+		s.ids = make(map[*types.StorageEvent]uint64)
+
+		for id := range events {
+			delete(events, id)
+		}
+
+		s.memory.push(false, s.fromFileEvents(record.Events, events)...)
+	default:
+		s.options.Logger.Warnf("File event storage, skipping unknown record %s in segment %d", record.Op, segmentIndex)
+	}
+}
+
+func (s *fileEventStorage) fromFileEvents(fileEvents []fileStorageEvent, events map[uint64]*types.StorageEvent) []*types.StorageEvent {
+	storageEvents := make([]*types.StorageEvent, 0, len(fileEvents))
+
+	for _, fileEvent := range fileEvents {
+		if fileEvent.Event == nil {
+			continue
+		}
+
+		fileEvent.Event.UserID = fileEvent.UserID
+		fileEvent.Event.DeviceID = fileEvent.DeviceID
+
+		storageEvent := &types.StorageEvent{
+			Event:         fileEvent.Event,
+			RetryAt:       fileEvent.RetryAt,
+			RetryCount:    fileEvent.RetryCount,
+			RetryInterval: fileEvent.RetryInterval,
+			Truncations:   fileEvent.Truncations,
+		}
+
+		s.ids[storageEvent] = fileEvent.ID
+		events[fileEvent.ID] = storageEvent
+		storageEvents = append(storageEvents, storageEvent)
+	}
+
+	return storageEvents
+}
+
+func (s *fileEventStorage) removeIDs(ids []uint64, events map[uint64]*types.StorageEvent) {
+	removed := make(map[*types.StorageEvent]struct{}, len(ids))
+
+	for _, id := range ids {
+		if event, ok := events[id]; ok {
+			removed[event] = struct{}{}
+			delete(events, id)
+			delete(s.ids, event)
+		}
+	}
+
+	if len(removed) == 0 {
+		return
+	}
+
+	s.memory.remove(func(event *types.StorageEvent) bool {
+		_, ok := removed[event]
+
+		return ok
+	})
+}
+
+// compact starts a new segment with a snapshot of the stored events and deletes older segments.
+func (s *fileEventStorage) compact() error {
+	// Leased events are not acknowledged yet, so they are kept in front of the other events.
+	events := append(s.leasedEvents(), s.memory.all()...)
+	snapshot := fileRecord{Op: fileRecordOpSnapshot, Events: s.toFileEvents(events...)}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("file event storage: can't encode snapshot: %w", err)
+	}
+
+	data = append(data, '\n')
+
+	segmentIndex := s.segmentIndex + 1
+	segmentPath := s.segmentPath(segmentIndex)
+	tmpPath := segmentPath + ".tmp"
+
+	if err := writeFileSynced(tmpPath, data); err != nil {
+		return fmt.Errorf("file event storage: can't write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, segmentPath); err != nil {
+		return fmt.Errorf("file event storage: can't rename snapshot: %w", err)
+	}
+
+	segment, err := os.OpenFile(segmentPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("file event storage: can't open segment: %w", err)
+	}
+
+	if s.segment != nil {
+		if err := s.segment.Close(); err != nil {
+			s.options.Logger.Warnf("File event storage, can't close segment %d: %s", s.segmentIndex, err)
+		}
+	}
+
+	s.segment = segment
+	s.segmentIndex = segmentIndex
+	s.appendedSize = 0
+	s.snapshotSize = int64(len(data))
+
+	segmentIndexes, err := s.segmentIndexes()
+	if err != nil {
+		return err
+	}
+
+	for _, index := range segmentIndexes {
+		if index < segmentIndex {
+			if err := os.Remove(s.segmentPath(index)); err != nil {
+				s.options.Logger.Warnf("File event storage, can't remove segment %d: %s", index, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *fileEventStorage) segmentIndexes() ([]uint64, error) {
+	entries, err := os.ReadDir(s.options.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("file event storage: can't read directory: %w", err)
+	}
+
+	var indexes []uint64
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentFilePrefix) || !strings.HasSuffix(name, segmentFileSuffix) {
+			continue
+		}
+
+		index, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentFilePrefix), segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+
+	return indexes, nil
+}
+
+func (s *fileEventStorage) segmentPath(segmentIndex uint64) string {
+	return filepath.Join(s.options.Directory, fmt.Sprintf("%s%020d%s", segmentFilePrefix, segmentIndex, segmentFileSuffix))
+}
+
+func fileEventIDs(fileEvents []fileStorageEvent) []uint64 {
+	ids := make([]uint64, len(fileEvents))
+	for i, fileEvent := range fileEvents {
+		ids[i] = fileEvent.ID
+	}
+
+	return ids
+}
+
+func writeFileSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,395 @@
+package storages_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/storages"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestFileEventStorage(t *testing.T) {
+	suite.Run(t, new(FileEventStorageSuite))
+}
+
+type FileEventStorageSuite struct {
+	suite.Suite
+}
+
+func (t *FileEventStorageSuite) TestSimple() {
+	event1 := &types.StorageEvent{Event: &types.Event{EventType: "event-A"}}
+	event2 := &types.StorageEvent{Event: &types.Event{EventType: "event-B"}}
+	event3 := &types.StorageEvent{Event: &types.Event{EventType: "event-C"}}
+	event4 := &types.StorageEvent{Event: &types.Event{EventType: "event-D"}}
+
+	require := t.Require()
+
+	s := t.createStorage(t.T().TempDir())
+	defer t.closeStorage(s)
+
+	s.PushNew(event1)
+	s.PushNew(event2)
+	s.PushNew(event3)
+	s.PushNew(event4)
+	require.Equal(4, s.Count(time.Time{}))
+
+	chunk := s.Pull(3, time.Time{})
+	require.Equal(1, s.Count(time.Time{}))
+	require.Equal([]*types.StorageEvent{event1, event2, event3}, chunk)
+
+	s.PushNew(event2)
+	s.PushNew(event3)
+	s.PushNew(event1)
+	require.Equal(4, s.Count(time.Time{}))
+
+	chunk = s.Pull(3, time.Time{})
+	require.Equal(1, s.Count(time.Time{}))
+	require.Equal([]*types.StorageEvent{event4, event2, event3}, chunk)
+
+	chunk = s.Pull(3, time.Time{})
+	require.Equal(0, s.Count(time.Time{}))
+	require.Equal([]*types.StorageEvent{event1}, chunk)
+
+	chunk = s.Pull(3, time.Time{})
+	require.Empty(chunk)
+}
+
+func (t *FileEventStorageSuite) TestReturnBack() {
+	event1 := &types.StorageEvent{Event: &types.Event{EventType: "event-A"}}
+	event2 := &types.StorageEvent{Event: &types.Event{EventType: "event-B"}}
+	event3 := &types.StorageEvent{Event: &types.Event{EventType: "event-C"}}
+	event4 := &types.StorageEvent{Event: &types.Event{EventType: "event-D"}}
+	event5 := &types.StorageEvent{Event: &types.Event{EventType: "event-E"}}
+
+	require := t.Require()
+
+	s := t.createStorage(t.T().TempDir())
+	defer t.closeStorage(s)
+
+	s.PushNew(event1)
+	s.PushNew(event2)
+	s.PushNew(event3)
+	s.PushNew(event4)
+	s.PushNew(event5)
+
+	chunk := s.Pull(4, time.Time{})
+	require.Equal([]*types.StorageEvent{event1, event2, event3, event4}, chunk)
+
+	now := time.Now()
+	event3.RetryAt = now.Add(time.Millisecond * 300)
+	event4.RetryAt = now.Add(time.Millisecond * 100)
+	s.ReturnBack(event2, event3, event4, event1)
+	require.Equal(3, s.Count(now))
+
+	chunk = s.Pull(4, now)
+	require.Equal([]*types.StorageEvent{event2, event1, event5}, chunk)
+
+	now = now.Add(time.Millisecond * 150)
+	chunk = s.Pull(4, now)
+	require.Equal([]*types.StorageEvent{event4}, chunk)
+
+	now = now.Add(time.Millisecond * 200)
+	chunk = s.Pull(4, now)
+	require.Equal([]*types.StorageEvent{event3}, chunk)
+	require.Equal(0, s.Count(now))
+}
+
+func (t *FileEventStorageSuite) TestReplay() {
+	directory := t.T().TempDir()
+	retryAt := time.Now().Add(time.Hour).Round(0)
+
+	require := t.Require()
+
+	s := t.createStorage(directory)
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A", UserID: "user-A"}})
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-B"}})
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-C"}})
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-D"}})
+
+	chunk := s.Pull(3, time.Time{})
+	require.Len(chunk, 3)
+
+	chunk[1].RetryAt = retryAt
+	chunk[1].RetryCount = 2
+	s.ReturnBack(chunk[0], chunk[1])
+	t.closeStorage(s)
+
+	s = t.createStorage(directory)
+	defer t.closeStorage(s)
+
+	require.Equal(2, s.Count(time.Time{}))
+	require.Equal(3, s.Count(retryAt.Add(time.Millisecond)))
+
+	chunk = s.Pull(3, retryAt.Add(time.Millisecond))
+	require.Len(chunk, 3)
+	require.Equal("event-A", chunk[0].EventType)
+	require.Equal("user-A", chunk[0].UserID)
+	require.Equal("event-D", chunk[1].EventType)
+	require.Equal("event-B", chunk[2].EventType)
+	require.True(retryAt.Equal(chunk[2].RetryAt))
+	require.Equal(2, chunk[2].RetryCount)
+}
+
+func (t *FileEventStorageSuite) TestCompaction() {
+	directory := t.T().TempDir()
+
+	require := t.Require()
+
+	s, err := storages.NewFileEventStorage(storages.FileEventStorageOptions{
+		Directory:      directory,
+		MaxSegmentSize: 512,
+		Logger:         noopLogger{},
+	})
+	require.NoError(err)
+
+	for i := 0; i < 50; i++ {
+		s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A"}})
+		require.Len(s.Pull(1, time.Time{}), 1)
+	}
+
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-B"}})
+	t.closeStorage(s)
+
+	segments, err := filepath.Glob(filepath.Join(directory, "segment-*.log"))
+	require.NoError(err)
+	require.Len(segments, 1)
+
+	s = t.createStorage(directory)
+	defer t.closeStorage(s)
+
+	chunk := s.Pull(10, time.Time{})
+	require.Len(chunk, 1)
+	require.Equal("event-B", chunk[0].EventType)
+}
+
+func (t *FileEventStorageSuite) TestCompaction_LargeBacklog() {
+	directory := t.T().TempDir()
+
+	require := t.Require()
+
+	s, err := storages.NewFileEventStorage(storages.FileEventStorageOptions{
+		Directory:      directory,
+		MaxSegmentSize: 512,
+		Logger:         noopLogger{},
+	})
+	require.NoError(err)
+
+	for i := 0; i < 100; i++ {
+		s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A"}})
+	}
+
+	t.closeStorage(s)
+
+	segments, err := filepath.Glob(filepath.Join(directory, "segment-*.log"))
+	require.NoError(err)
+	require.Len(segments, 1)
+
+	// The snapshot grows with the backlog, so it's rewritten a few times instead of on every push.
+	segmentIndex, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(segments[0]), "segment-"), ".log"), 10, 64)
+	require.NoError(err)
+	require.Less(segmentIndex, uint64(10))
+
+	s = t.createStorage(directory)
+	defer t.closeStorage(s)
+
+	require.Equal(100, s.Count(time.Time{}))
+}
+
+func (t *FileEventStorageSuite) TestReplay_PropertyTypes() {
+	directory := t.T().TempDir()
+
+	require := t.Require()
+
+	s := t.createStorage(directory)
+	s.PushNew(&types.StorageEvent{Event: &types.Event{
+		EventType: "event-A",
+		EventProperties: map[string]interface{}{
+			"count":  float64(3),
+			"price":  1.5,
+			"nested": map[string]interface{}{"items": []interface{}{float64(1), "a", true}},
+		},
+	}})
+	t.closeStorage(s)
+
+	s = t.createStorage(directory)
+	defer t.closeStorage(s)
+
+	chunk := s.Pull(1, time.Time{})
+	require.Len(chunk, 1)
+	require.Equal(map[string]interface{}{
+		"count":  float64(3),
+		"price":  1.5,
+		"nested": map[string]interface{}{"items": []interface{}{float64(1), "a", true}},
+	}, chunk[0].EventProperties)
+}
+
+func (t *FileEventStorageSuite) TestReplay_CorruptedTail() {
+	directory := t.T().TempDir()
+
+	require := t.Require()
+
+	s := t.createStorage(directory)
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A"}})
+	t.closeStorage(s)
+
+	segments, err := filepath.Glob(filepath.Join(directory, "segment-*.log"))
+	require.NoError(err)
+	require.Len(segments, 1)
+
+	file, err := os.OpenFile(segments[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(err)
+	_, err = file.WriteString(`{"op":"push","events":[{"id":7,"eve`)
+	require.NoError(err)
+	require.NoError(file.Close())
+
+	s = t.createStorage(directory)
+	defer t.closeStorage(s)
+
+	chunk := s.Pull(10, time.Time{})
+	require.Len(chunk, 1)
+	require.Equal("event-A", chunk[0].EventType)
+}
+
+func (t *FileEventStorageSuite) TestLease() {
+	event1 := &types.StorageEvent{Event: &types.Event{EventType: "event-A"}}
+	event2 := &types.StorageEvent{Event: &types.Event{EventType: "event-B"}}
+	event3 := &types.StorageEvent{Event: &types.Event{EventType: "event-C"}}
+
+	require := t.Require()
+
+	s := t.createStorage(t.T().TempDir()).(types.LeasingEventStorage)
+	defer t.closeStorage(s)
+
+	s.PushNew(event1)
+	s.PushNew(event2)
+	s.PushNew(event3)
+
+	lease := s.Lease(2, time.Time{}, time.Minute)
+	require.Equal([]*types.StorageEvent{event1, event2}, lease.Events)
+	require.Equal(1, s.Count(time.Time{}))
+
+	s.Ack(lease, event1)
+	s.Nack(lease, event2)
+	require.Equal(2, s.Count(time.Time{}))
+
+	lease = s.Lease(3, time.Time{}, time.Minute)
+	require.Equal([]*types.StorageEvent{event2, event3}, lease.Events)
+
+	s.Ack(lease, event2, event3)
+	require.Equal(0, s.Count(time.Time{}))
+	require.Empty(s.Lease(3, time.Time{}, time.Minute).Events)
+}
+
+func (t *FileEventStorageSuite) TestLease_NotAcknowledgedBeforeRestart() {
+	directory := t.T().TempDir()
+
+	require := t.Require()
+
+	s := t.createStorage(directory).(types.LeasingEventStorage)
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A"}})
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-B"}})
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-C"}})
+
+	lease := s.Lease(3, time.Time{}, time.Minute)
+	require.Len(lease.Events, 3)
+
+	s.Ack(lease, lease.Events[0])
+	t.closeStorage(s)
+
+	s = t.createStorage(directory).(types.LeasingEventStorage)
+	defer t.closeStorage(s)
+
+	lease = s.Lease(3, time.Time{}, time.Minute)
+	require.Len(lease.Events, 2)
+	require.Equal("event-B", lease.Events[0].EventType)
+	require.Equal("event-C", lease.Events[1].EventType)
+}
+
+func (t *FileEventStorageSuite) TestLease_Nack() {
+	directory := t.T().TempDir()
+	retryAt := time.Now().Add(time.Hour).Round(0)
+
+	require := t.Require()
+
+	s := t.createStorage(directory).(types.LeasingEventStorage)
+	s.PushNew(&types.StorageEvent{Event: &types.Event{EventType: "event-A"}})
+
+	lease := s.Lease(1, time.Time{}, time.Minute)
+	require.Len(lease.Events, 1)
+
+	lease.Events[0].RetryAt = retryAt
+	lease.Events[0].RetryCount = 1
+	s.Nack(lease, lease.Events...)
+	require.Equal(0, s.Count(time.Time{}))
+	t.closeStorage(s)
+
+	s = t.createStorage(directory).(types.LeasingEventStorage)
+	defer t.closeStorage(s)
+
+	require.Equal(0, s.Count(time.Time{}))
+
+	lease = s.Lease(1, retryAt.Add(time.Millisecond), time.Minute)
+	require.Len(lease.Events, 1)
+	require.True(retryAt.Equal(lease.Events[0].RetryAt))
+	require.Equal(1, lease.Events[0].RetryCount)
+}
+
+func (t *FileEventStorageSuite) TestLease_Expired() {
+	event1 := &types.StorageEvent{Event: &types.Event{EventType: "event-A"}}
+	event2 := &types.StorageEvent{Event: &types.Event{EventType: "event-B"}}
+
+	require := t.Require()
+
+	s := t.createStorage(t.T().TempDir()).(types.LeasingEventStorage)
+	defer t.closeStorage(s)
+
+	s.PushNew(event1)
+	s.PushNew(event2)
+
+	lease := s.Lease(1, time.Time{}, time.Millisecond*10)
+	require.Equal([]*types.StorageEvent{event1}, lease.Events)
+	require.Equal(1, s.Count(time.Time{}))
+
+	time.Sleep(time.Millisecond * 20)
+	require.Equal(2, s.Count(time.Time{}))
+
+	// A late acknowledgement still removes the delivered event.
+	s.Ack(lease, event1)
+	require.Equal(1, s.Count(time.Time{}))
+	require.Equal([]*types.StorageEvent{event2}, s.Lease(2, time.Time{}, time.Minute).Events)
+}
+
+func (t *FileEventStorageSuite) createStorage(directory string) types.EventStorage {
+	s, err := storages.NewFileEventStorage(storages.FileEventStorageOptions{
+		Directory: directory,
+		Logger:    noopLogger{},
+	})
+	t.Require().NoError(err)
+
+	return s
+}
+
+func (t *FileEventStorageSuite) closeStorage(s types.EventStorage) {
+	t.Require().NoError(s.(io.Closer).Close())
+}
+
+type noopLogger struct{}
+
+func (l noopLogger) Debugf(string, ...interface{}) {
+}
+
+func (l noopLogger) Infof(string, ...interface{}) {
+}
+
+func (l noopLogger) Warnf(string, ...interface{}) {
+}
+
+func (l noopLogger) Errorf(string, ...interface{}) {
+}
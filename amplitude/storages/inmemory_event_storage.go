@@ -120,3 +120,40 @@ func (s *inMemoryEventStorage) addRetriedEvent(event *types.StorageEvent) {
 		s.retriedEvents[index] = event
 	}
 }
+
+// all returns every stored event in pull order.
+func (s *inMemoryEventStorage) all() []*types.StorageEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]*types.StorageEvent, 0, len(s.events)+len(s.retriedEvents))
+	events = append(events, s.events...)
+	events = append(events, s.retriedEvents...)
+
+	return events
+}
+
+// remove deletes every stored event matching the predicate.
+func (s *inMemoryEventStorage) remove(predicate func(event *types.StorageEvent) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = filterStorageEvents(s.events, predicate)
+	s.retriedEvents = filterStorageEvents(s.retriedEvents, predicate)
+}
+
+func filterStorageEvents(events []*types.StorageEvent, predicate func(event *types.StorageEvent) bool) []*types.StorageEvent {
+	kept := events[:0]
+
+	for _, event := range events {
+		if !predicate(event) {
+			kept = append(kept, event)
+		}
+	}
+
+	for i := len(kept); i < len(events); i++ {
+		events[i] = nil
+	}
+
+	return kept
+}
@@ -1,34 +1,81 @@
 package amplitude
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
 	"github.com/amplitude/analytics-go/amplitude/internal"
+	"github.com/amplitude/analytics-go/amplitude/metrics"
+	"github.com/amplitude/analytics-go/amplitude/tracing"
 )
 
 type timeline struct {
 	logger             Logger
+	metrics            Metrics
+	tracer             Tracer
 	beforePlugins      []BeforePlugin
 	enrichmentPlugins  []EnrichmentPlugin
 	destinationPlugins []DestinationPlugin
 	mu                 sync.RWMutex
+
+	// resultObservers have their own lock, since results are observed while destinations flush under mu.
+	resultObservers   []ResultObserverPlugin
+	resultObserversMu sync.RWMutex
 }
 
-func (t *timeline) Process(event *Event) {
+func (t *timeline) Process(ctx context.Context, event *Event) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	event = t.applyBeforePlugins(event)
+	event = t.applyBeforeAndEnrichmentPlugins(ctx, event)
 	if event == nil {
 		return
 	}
 
+	t.applyDestinationPlugins(ctx, event)
+}
+
+// ProcessContext is like Process, but reports whether every destination accepted the event.
+func (t *timeline) ProcessContext(ctx context.Context, event *Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	event = t.applyBeforeAndEnrichmentPlugins(ctx, event)
+	if event == nil {
+		return ErrEventFiltered
+	}
+
+	return t.applyDestinationPluginsContext(ctx, event)
+}
+
+func (t *timeline) applyBeforeAndEnrichmentPlugins(ctx context.Context, event *Event) *Event {
+	_, span := t.tracer.Start(ctx, tracing.ProcessSpan)
+	defer span.End()
+
+	t.metrics.AddCounter(metrics.EventsTrackedTotal, 1, nil)
+
+	event = t.applyBeforePlugins(event)
+	if event == nil {
+		t.metrics.AddCounter(metrics.EventsFilteredTotal, 1, map[string]string{"stage": "before"})
+		span.SetAttribute(tracing.FilteredAttribute, true)
+
+		return nil
+	}
+
 	event = t.applyEnrichmentPlugins(event)
 	if event == nil {
-		return
+		t.metrics.AddCounter(metrics.EventsFilteredTotal, 1, map[string]string{"stage": "enrichment"})
+		span.SetAttribute(tracing.FilteredAttribute, true)
+
+		return nil
 	}
 
-	t.applyDestinationPlugins(event)
+	return event
 }
 
 func (t *timeline) applyBeforePlugins(event *Event) *Event {
@@ -57,7 +104,7 @@ func (t *timeline) applyEnrichmentPlugins(event *Event) *Event {
 	return result
 }
 
-func (t *timeline) applyDestinationPlugins(event *Event) {
+func (t *timeline) applyDestinationPlugins(ctx context.Context, event *Event) {
 	var wg sync.WaitGroup
 
 	for _, plugin := range t.destinationPlugins {
@@ -65,12 +112,39 @@ func (t *timeline) applyDestinationPlugins(event *Event) {
 
 		wg.Add(1)
 
-		go t.executeDestinationPlugin(plugin, &clone, &wg)
+		go t.executeDestinationPlugin(ctx, plugin, &clone, &wg)
 	}
 
 	wg.Wait()
 }
 
+func (t *timeline) applyDestinationPluginsContext(ctx context.Context, event *Event) error {
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(t.destinationPlugins))
+
+	for i, plugin := range t.destinationPlugins {
+		clone := event.Clone()
+
+		wg.Add(1)
+
+		go func(i int, plugin DestinationPlugin, event *Event) {
+			defer wg.Done()
+			errs[i] = t.executeDestinationPluginContext(ctx, plugin, event)
+		}(i, plugin, &clone)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("destination %s: %w", t.destinationPlugins[i].Name(), err)
+		}
+	}
+
+	return nil
+}
+
 func (t *timeline) executeBeforePlugin(plugin BeforePlugin, event *Event) (result *Event) {
 	return plugin.Execute(event)
 }
@@ -79,15 +153,50 @@ func (t *timeline) executeEnrichmentPlugin(plugin EnrichmentPlugin, event *Event
 	return plugin.Execute(event)
 }
 
-func (t *timeline) executeDestinationPlugin(plugin DestinationPlugin, event *Event, wg *sync.WaitGroup) {
+func (t *timeline) executeDestinationPlugin(ctx context.Context, plugin DestinationPlugin, event *Event, wg *sync.WaitGroup) {
 	defer wg.Done()
+
+	span := t.startEnqueueSpan(ctx, plugin)
+	defer span.End()
+
 	plugin.Execute(event)
 }
 
+func (t *timeline) executeDestinationPluginContext(ctx context.Context, plugin DestinationPlugin, event *Event) error {
+	span := t.startEnqueueSpan(ctx, plugin)
+	defer span.End()
+
+	if plugin, ok := plugin.(ContextDestinationPlugin); ok {
+		err := plugin.ExecuteContext(ctx, event)
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		return err
+	}
+
+	plugin.Execute(event)
+
+	return nil
+}
+
+func (t *timeline) startEnqueueSpan(ctx context.Context, plugin DestinationPlugin) Span {
+	_, span := t.tracer.Start(ctx, tracing.EnqueueSpan)
+	span.SetAttribute(tracing.PluginAttribute, plugin.Name())
+
+	return span
+}
+
 func (t *timeline) AddPlugin(plugin Plugin) Plugin {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	if observer, ok := plugin.(ResultObserverPlugin); ok {
+		t.resultObserversMu.Lock()
+		t.resultObservers = append(t.resultObservers, observer)
+		t.resultObserversMu.Unlock()
+	}
+
 	switch plugin.Type() {
 	case PluginTypeBefore:
 		plugin, ok := plugin.(BeforePlugin)
@@ -153,6 +262,35 @@ func (t *timeline) RemovePlugin(pluginName string) {
 			t.destinationPlugins = append(t.destinationPlugins[:i], t.destinationPlugins[i+1:]...)
 		}
 	}
+
+	t.resultObserversMu.Lock()
+	defer t.resultObserversMu.Unlock()
+
+	for i := len(t.resultObservers) - 1; i >= 0; i-- {
+		if t.resultObservers[i].Name() == pluginName {
+			t.resultObservers = append(t.resultObservers[:i], t.resultObservers[i+1:]...)
+		}
+	}
+}
+
+// ObserveResult passes a result of the Amplitude destination to every ResultObserverPlugin.
+func (t *timeline) ObserveResult(result ExecuteResult) {
+	t.resultObserversMu.RLock()
+	defer t.resultObserversMu.RUnlock()
+
+	for _, observer := range t.resultObservers {
+		t.observeResult(observer, result)
+	}
+}
+
+func (t *timeline) observeResult(observer ResultObserverPlugin, result ExecuteResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.logger.Errorf("Panic in plugin %s.ObserveResult: %s", observer.Name(), r)
+		}
+	}()
+
+	observer.ObserveResult(result)
 }
 
 func (t *timeline) Flush() {
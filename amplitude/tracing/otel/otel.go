@@ -0,0 +1,75 @@
+// Package otel adapts an OpenTelemetry TracerProvider to the TracerProvider of the SDK, see Config.TracerProvider.
+// It's a separate module, so the SDK doesn't depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// NewTracerProvider returns a TracerProvider that starts OpenTelemetry spans with the provider.
+func NewTracerProvider(provider trace.TracerProvider) types.TracerProvider {
+	return tracerProvider{provider: provider}
+}
+
+type tracerProvider struct {
+	provider trace.TracerProvider
+}
+
+func (p tracerProvider) Tracer(name string) types.Tracer {
+	return tracer{tracer: p.provider.Tracer(name)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, spanName string) (context.Context, types.Span) {
+	ctx, s := t.tracer.Start(ctx, spanName)
+
+	return ctx, span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(attributeOf(key, value))
+}
+
+// RecordError records the error as an exception event and sets the status of the span to Error.
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s span) End() {
+	s.span.End()
+}
+
+// attributeOf converts an attribute value of the SDK, values of other types are formatted as strings.
+func attributeOf(key string, value interface{}) attribute.KeyValue {
+	switch value := value.(type) {
+	case string:
+		return attribute.String(key, value)
+	case bool:
+		return attribute.Bool(key, value)
+	case int:
+		return attribute.Int(key, value)
+	case int64:
+		return attribute.Int64(key, value)
+	case float64:
+		return attribute.Float64(key, value)
+	case []string:
+		return attribute.StringSlice(key, value)
+	default:
+		return attribute.String(key, fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,53 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/amplitude/analytics-go/amplitude/tracing"
+	"github.com/amplitude/analytics-go/amplitude/tracing/otel"
+)
+
+func TestOtel(t *testing.T) {
+	suite.Run(t, new(OtelSuite))
+}
+
+type OtelSuite struct {
+	suite.Suite
+}
+
+func (t *OtelSuite) TestTracerProvider() {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := tracing.NewTracer(otel.NewTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	ctx, parent := tracer.Start(context.Background(), tracing.TrackSpan)
+	_, child := tracer.Start(ctx, tracing.UploadSpan)
+	child.SetAttribute(tracing.BatchSizeAttribute, 2)
+	child.SetAttribute(tracing.ServerURLAttribute, "https://api2.amplitude.com/2/httpapi")
+	child.SetAttribute("amplitude.delay", struct{ Seconds int }{1})
+	child.RecordError(errors.New("connection refused"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+
+	require := t.Require()
+	require.Len(spans, 2)
+	require.Equal(tracing.UploadSpan, spans[0].Name())
+	require.Equal(tracing.TracerName, spans[0].InstrumentationLibrary().Name)
+	require.Equal(spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal([]attribute.KeyValue{
+		attribute.Int(tracing.BatchSizeAttribute, 2),
+		attribute.String(tracing.ServerURLAttribute, "https://api2.amplitude.com/2/httpapi"),
+		attribute.String("amplitude.delay", "{1}"),
+	}, spans[0].Attributes())
+	require.Equal(codes.Error, spans[0].Status().Code)
+	require.Len(spans[0].Events(), 1)
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// TracerName is the name SDK components pass to TracerProvider.Tracer.
+const TracerName = "github.com/amplitude/analytics-go"
+
+// Names of spans started by the SDK.
+const (
+	// TrackSpan covers a Client call from building the event until every destination has accepted it.
+	TrackSpan = "amplitude.track"
+	// ProcessSpan covers before and enrichment plugins.
+	ProcessSpan = "amplitude.process"
+	// EnqueueSpan covers passing the event to one destination plugin.
+	EnqueueSpan = "amplitude.enqueue"
+	// UploadSpan covers one batch upload to the Amplitude HTTP API.
+	UploadSpan = "amplitude.upload"
+)
+
+// Span attribute keys.
+const (
+	EventTypeAttribute  = "amplitude.event_type"
+	PluginAttribute     = "amplitude.plugin"
+	FilteredAttribute   = "amplitude.filtered"
+	BatchSizeAttribute  = "amplitude.batch_size"
+	StatusCodeAttribute = "http.status_code"
+	RetryCountAttribute = "amplitude.retry_count"
+	ServerURLAttribute  = "http.url"
+)
+
+// NewNoopTracerProvider returns a TracerProvider whose spans do nothing.
+func NewNoopTracerProvider() types.TracerProvider {
+	return noopTracerProvider{}
+}
+
+// NewTracer returns the SDK tracer of the provider, or a no-op tracer if the provider is nil.
+func NewTracer(provider types.TracerProvider) types.Tracer {
+	if provider == nil {
+		provider = noopTracerProvider{}
+	}
+
+	return provider.Tracer(TracerName)
+}
+
+type noopTracerProvider struct{}
+
+func (noopTracerProvider) Tracer(string) types.Tracer {
+	return noopTracer{}
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, types.Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {
+}
+
+func (noopSpan) RecordError(error) {
+}
+
+func (noopSpan) End() {
+}
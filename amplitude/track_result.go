@@ -0,0 +1,138 @@
+package amplitude
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+)
+
+// TrackResult is the pending delivery result of an event tracked with TrackWithResult.
+type TrackResult struct {
+	event  Event
+	done   chan struct{}
+	result ExecuteResult
+}
+
+func newTrackResult(event Event) *TrackResult {
+	return &TrackResult{event: event, done: make(chan struct{})}
+}
+
+// Done returns a channel that is closed once the Amplitude destination has processed the event.
+func (r *TrackResult) Done() <-chan struct{} {
+	return r.done
+}
+
+// Result blocks until the delivery result is available and returns it.
+func (r *TrackResult) Result() ExecuteResult {
+	<-r.done
+
+	return r.result
+}
+
+// Wait waits for the delivery result until the context is done.
+func (r *TrackResult) Wait(ctx context.Context) (ExecuteResult, error) {
+	select {
+	case <-r.done:
+		return r.result, nil
+	case <-ctx.Done():
+		return ExecuteResult{}, ctx.Err()
+	}
+}
+
+func (r *TrackResult) resolve(result ExecuteResult) {
+	r.result = result
+	close(r.done)
+}
+
+// trackResults keeps TrackResults waiting for a result of the Amplitude destination, keyed by InsertID.
+type trackResults struct {
+	// waiting is the number of pending TrackResults, so results are resolved without locking while there are none.
+	// It's first in the struct to be aligned for atomic operations on 32-bit platforms.
+	waiting int64
+
+	pending map[string][]*TrackResult
+	mu      sync.Mutex
+}
+
+func newTrackResults() *trackResults {
+	return &trackResults{pending: make(map[string][]*TrackResult)}
+}
+
+func (r *trackResults) add(event *Event) *TrackResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := newTrackResult(*event)
+	r.pending[event.InsertID] = append(r.pending[event.InsertID], result)
+	atomic.AddInt64(&r.waiting, 1)
+
+	return result
+}
+
+func (r *trackResults) remove(insertID string, result *TrackResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := r.pending[insertID]
+	for i := range results {
+		if results[i] == result {
+			results = append(results[:i], results[i+1:]...)
+			atomic.AddInt64(&r.waiting, -1)
+
+			break
+		}
+	}
+
+	r.setPending(insertID, results)
+}
+
+// resolve resolves the oldest TrackResult waiting for the event of the result.
+func (r *trackResults) resolve(result ExecuteResult) {
+	if result.Event == nil || result.Event.InsertID == "" || atomic.LoadInt64(&r.waiting) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := r.pending[result.Event.InsertID]
+	if len(results) == 0 {
+		return
+	}
+
+	results[0].resolve(result)
+	atomic.AddInt64(&r.waiting, -1)
+	r.setPending(result.Event.InsertID, results[1:])
+}
+
+// resolveAll resolves every pending TrackResult with UnresolvedOnShutdownCode,
+// e.g. results of events still waiting for a retry in storage on Shutdown.
+func (r *trackResults) resolveAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for insertID, results := range r.pending {
+		for _, result := range results {
+			event := result.event
+			result.resolve(ExecuteResult{
+				PluginName: constants.AmplitudePluginName,
+				Event:      &event,
+				Code:       constants.UnresolvedOnShutdownCode,
+				Message:    "Client is shut down before the event was delivered",
+			})
+		}
+
+		atomic.AddInt64(&r.waiting, -int64(len(results)))
+		delete(r.pending, insertID)
+	}
+}
+
+func (r *trackResults) setPending(insertID string, results []*TrackResult) {
+	if len(results) == 0 {
+		delete(r.pending, insertID)
+	} else {
+		r.pending[insertID] = results
+	}
+}
@@ -0,0 +1,207 @@
+package trackingplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// Violation is a difference of an event from the tracking plan, Property is empty if it's about the whole event.
+type Violation struct {
+	EventType string
+	Property  string
+	Message   string
+}
+
+func (v Violation) String() string {
+	if v.Property == "" {
+		return fmt.Sprintf("%s: %s", v.EventType, v.Message)
+	}
+
+	return fmt.Sprintf("%s: property %q %s", v.EventType, v.Property, v.Message)
+}
+
+type CheckerOptions struct {
+	// AllowUnplannedEvents accepts events that are not in the tracking plan.
+	// Identify, group identify and revenue events are accepted unless they are planned.
+	AllowUnplannedEvents bool
+}
+
+// Checker compares events with a tracking plan.
+type Checker interface {
+	Check(event *types.Event) []Violation
+}
+
+func NewChecker(plan *types.TrackingPlan, options CheckerOptions) Checker {
+	events := make(map[string]*types.TrackingPlanEvent, len(plan.Events))
+	for i := range plan.Events {
+		events[plan.Events[i].Name] = &plan.Events[i]
+	}
+
+	return &checker{events: events, options: options}
+}
+
+type checker struct {
+	events  map[string]*types.TrackingPlanEvent
+	options CheckerOptions
+}
+
+// Check returns the violations of the event properties, sorted by property name.
+func (c *checker) Check(event *types.Event) []Violation {
+	plannedEvent, ok := c.events[event.EventType]
+	if !ok {
+		switch {
+		case c.options.AllowUnplannedEvents,
+			event.EventType == constants.IdentifyEventType,
+			event.EventType == constants.GroupIdentifyEventType,
+			event.EventType == constants.RevenueEventType:
+			return nil
+		}
+
+		return []Violation{{EventType: event.EventType, Message: "is not planned"}}
+	}
+
+	var violations []Violation
+
+	planned := make(map[string]struct{}, len(plannedEvent.Properties))
+
+	for _, property := range plannedEvent.Properties {
+		planned[property.Name] = struct{}{}
+
+		if message := checkProperty(property, event.EventProperties[property.Name]); message != "" {
+			violations = append(violations, Violation{EventType: event.EventType, Property: property.Name, Message: message})
+		}
+	}
+
+	if !plannedEvent.AllowUnplannedProperties {
+		for name := range event.EventProperties {
+			if _, ok := planned[name]; !ok {
+				violations = append(violations, Violation{EventType: event.EventType, Property: name, Message: "is not planned"})
+			}
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].Property < violations[j].Property
+	})
+
+	return violations
+}
+
+// checkProperty describes why the value doesn't match the planned property, or returns an empty string.
+// A nil value is treated as a missing property.
+func checkProperty(property types.TrackingPlanProperty, value interface{}) string {
+	value, ok := indirect(value)
+	if !ok {
+		if property.Required {
+			return "is required"
+		}
+
+		return ""
+	}
+
+	if !hasType(value, property.Type) {
+		return "must be of type " + string(property.Type)
+	}
+
+	if len(property.Enum) == 0 {
+		return ""
+	}
+
+	for _, allowed := range property.Enum {
+		if equalValues(allowed, value) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("must be one of %v", property.Enum)
+}
+
+// indirect dereferences pointers and returns false for nil values.
+func indirect(value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+func hasType(value interface{}, propertyType types.PropertyType) bool {
+	switch propertyType {
+	case "", types.PropertyTypeAny:
+		return true
+	case types.PropertyTypeString:
+		_, ok := value.(string)
+
+		return ok
+	case types.PropertyTypeBoolean:
+		_, ok := value.(bool)
+
+		return ok
+	case types.PropertyTypeNumber:
+		_, ok := toFloat(value)
+
+		return ok
+	case types.PropertyTypeInteger:
+		number, ok := toFloat(value)
+
+		return ok && number == math.Trunc(number)
+	case types.PropertyTypeArray:
+		kind := reflect.TypeOf(value).Kind()
+
+		return kind == reflect.Slice || kind == reflect.Array
+	case types.PropertyTypeObject:
+		kind := reflect.TypeOf(value).Kind()
+
+		return kind == reflect.Map || kind == reflect.Struct
+	}
+
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+
+		return f, err == nil
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// equalValues compares numbers by value regardless of their Go types, e.g. an int of the plan with a float64 property.
+func equalValues(allowed interface{}, value interface{}) bool {
+	allowedNumber, allowedIsNumber := toFloat(allowed)
+	number, isNumber := toFloat(value)
+
+	if allowedIsNumber || isNumber {
+		return allowedIsNumber && isNumber && allowedNumber == number
+	}
+
+	return reflect.DeepEqual(allowed, value)
+}
@@ -0,0 +1,110 @@
+package trackingplan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/constants"
+	"github.com/amplitude/analytics-go/amplitude/trackingplan"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestChecker(t *testing.T) {
+	suite.Run(t, new(CheckerSuite))
+}
+
+type CheckerSuite struct {
+	suite.Suite
+}
+
+var checkerPlan = &types.TrackingPlan{
+	Events: []types.TrackingPlanEvent{
+		{
+			Name: "Song Played",
+			Properties: []types.TrackingPlanProperty{
+				{Name: "genre", Type: types.PropertyTypeString, Required: true, Enum: []interface{}{"rock", "jazz"}},
+				{Name: "rating", Type: types.PropertyTypeInteger, Enum: []interface{}{1, 2, 3}},
+				{Name: "duration", Type: types.PropertyTypeNumber},
+				{Name: "live", Type: types.PropertyTypeBoolean},
+				{Name: "artists", Type: types.PropertyTypeArray},
+				{Name: "album", Type: types.PropertyTypeObject},
+				{Name: "extra"},
+			},
+		},
+		{Name: "App Opened", AllowUnplannedProperties: true},
+	},
+}
+
+func (t *CheckerSuite) TestCheck_Valid() {
+	checker := trackingplan.NewChecker(checkerPlan, trackingplan.CheckerOptions{})
+	genre := "jazz"
+
+	require := t.Require()
+	require.Empty(checker.Check(&types.Event{
+		EventType: "Song Played",
+		EventProperties: map[string]interface{}{
+			"genre":    &genre,
+			"rating":   float64(2),
+			"duration": float32(1.5),
+			"live":     true,
+			"artists":  []string{"A", "B"},
+			"album":    map[string]interface{}{"name": "C"},
+			"extra":    struct{}{},
+		},
+	}))
+	require.Empty(checker.Check(&types.Event{
+		EventType:       "App Opened",
+		EventProperties: map[string]interface{}{"any": 1},
+	}))
+	require.Empty(checker.Check(&types.Event{EventType: constants.IdentifyEventType}))
+	require.Empty(checker.Check(&types.Event{EventType: constants.RevenueEventType}))
+}
+
+func (t *CheckerSuite) TestCheck_Violations() {
+	checker := trackingplan.NewChecker(checkerPlan, trackingplan.CheckerOptions{})
+
+	violations := checker.Check(&types.Event{
+		EventType: "Song Played",
+		EventProperties: map[string]interface{}{
+			"genre":    nil,
+			"rating":   4,
+			"duration": "long",
+			"live":     "yes",
+			"artists":  "A",
+			"album":    []string{},
+			"unknown":  1,
+		},
+	})
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.String()
+	}
+
+	require := t.Require()
+	require.Equal([]string{
+		`Song Played: property "album" must be of type object`,
+		`Song Played: property "artists" must be of type array`,
+		`Song Played: property "duration" must be of type number`,
+		`Song Played: property "genre" is required`,
+		`Song Played: property "live" must be of type boolean`,
+		`Song Played: property "rating" must be one of [1 2 3]`,
+		`Song Played: property "unknown" is not planned`,
+	}, messages)
+
+	require.Equal([]trackingplan.Violation{{EventType: "Song Played", Property: "rating", Message: "must be of type integer"}},
+		checker.Check(&types.Event{
+			EventType:       "Song Played",
+			EventProperties: map[string]interface{}{"genre": "rock", "rating": 1.5},
+		}))
+
+	require.Equal([]trackingplan.Violation{{EventType: "Song Skipped", Message: "is not planned"}},
+		checker.Check(&types.Event{EventType: "Song Skipped"}))
+}
+
+func (t *CheckerSuite) TestCheck_AllowUnplannedEvents() {
+	checker := trackingplan.NewChecker(checkerPlan, trackingplan.CheckerOptions{AllowUnplannedEvents: true})
+
+	t.Require().Empty(checker.Check(&types.Event{EventType: "Song Skipped"}))
+}
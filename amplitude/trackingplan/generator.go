@@ -0,0 +1,322 @@
+package trackingplan
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+// initialisms are words written in upper case in Go identifiers.
+var initialisms = map[string]struct{}{
+	"API": {}, "HTML": {}, "HTTP": {}, "ID": {}, "IP": {}, "JSON": {}, "SDK": {}, "UI": {}, "URL": {}, "UUID": {},
+}
+
+var goTypes = map[types.PropertyType]string{
+	"":                        "interface{}",
+	types.PropertyTypeAny:     "interface{}",
+	types.PropertyTypeString:  "string",
+	types.PropertyTypeNumber:  "float64",
+	types.PropertyTypeInteger: "int",
+	types.PropertyTypeBoolean: "bool",
+	types.PropertyTypeArray:   "[]interface{}",
+	types.PropertyTypeObject:  "map[string]interface{}",
+}
+
+type GeneratorOptions struct {
+	// Package is the name of the generated package.
+	Package string
+
+	// Source is the file the tracking plan was loaded from, mentioned in the header of the generated code.
+	Source string
+}
+
+// Generate returns Go code with a function for every event of the tracking plan that builds an amplitude.Event.
+// Properties are passed as a struct, e.g. SongPlayed(userID, SongPlayedProps{Genre: SongPlayedGenreRock}),
+// optional properties are pointers, and optional enums are left out if they are empty.
+// Events have the Plan of the tracking plan if it's set.
+func Generate(plan *types.TrackingPlan, options GeneratorOptions) ([]byte, error) {
+	if !token.IsIdentifier(options.Package) {
+		return nil, fmt.Errorf("invalid package name %q", options.Package)
+	}
+
+	data := generatorData{Package: options.Package, Source: options.Source}
+
+	data.Plan = planLiteral(plan.Plan)
+
+	identifiers := map[string]string{"Plan": "the tracking plan"}
+
+	for _, event := range plan.Events {
+		generatedEvent, err := newGeneratedEvent(event, identifiers)
+		if err != nil {
+			return nil, err
+		}
+
+		data.Events = append(data.Events, generatedEvent)
+	}
+
+	var buf bytes.Buffer
+	if err := generatorTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+type generatorData struct {
+	Package string
+	Source  string
+	Plan    string
+	Events  []generatedEvent
+}
+
+type generatedEvent struct {
+	Name       string
+	Func       string
+	PropsType  string
+	Properties []generatedProperty
+	Extra      bool
+}
+
+type generatedProperty struct {
+	Name     string
+	Field    string
+	Type     string
+	Required bool
+	Check    string
+	Value    string
+	EnumType string
+	Enums    []generatedEnum
+}
+
+type generatedEnum struct {
+	Const string
+	Value string
+}
+
+func newGeneratedEvent(event types.TrackingPlanEvent, identifiers map[string]string) (generatedEvent, error) {
+	generated := generatedEvent{
+		Name:  event.Name,
+		Func:  identifier(event.Name),
+		Extra: event.AllowUnplannedProperties,
+	}
+
+	if err := declare(identifiers, generated.Func, fmt.Sprintf("event %q", event.Name)); err != nil {
+		return generated, err
+	}
+
+	if len(event.Properties) > 0 || generated.Extra {
+		generated.PropsType = generated.Func + "Props"
+		if err := declare(identifiers, generated.PropsType, fmt.Sprintf("properties of event %q", event.Name)); err != nil {
+			return generated, err
+		}
+	}
+
+	fields := map[string]string{"Extra": "unplanned properties"}
+
+	for _, property := range event.Properties {
+		generatedProperty, err := newGeneratedProperty(generated.Func, property, identifiers)
+		if err != nil {
+			return generated, fmt.Errorf("event %q: %w", event.Name, err)
+		}
+
+		if err := declare(fields, generatedProperty.Field, fmt.Sprintf("property %q", property.Name)); err != nil {
+			return generated, fmt.Errorf("event %q: %w", event.Name, err)
+		}
+
+		generated.Properties = append(generated.Properties, generatedProperty)
+	}
+
+	return generated, nil
+}
+
+func newGeneratedProperty(
+	eventIdentifier string, property types.TrackingPlanProperty, identifiers map[string]string,
+) (generatedProperty, error) {
+	generated := generatedProperty{
+		Name:     property.Name,
+		Field:    identifier(property.Name),
+		Type:     goTypes[property.Type],
+		Required: property.Required,
+	}
+
+	if generated.Field == "" {
+		return generated, fmt.Errorf("property %q has no letters for a Go name", property.Name)
+	}
+
+	if property.Type == types.PropertyTypeString && len(property.Enum) > 0 {
+		generated.EnumType = eventIdentifier + generated.Field
+		if err := declare(identifiers, generated.EnumType, fmt.Sprintf("values of property %q", property.Name)); err != nil {
+			return generated, err
+		}
+
+		for _, value := range property.Enum {
+			value := fmt.Sprint(value)
+
+			enum := generatedEnum{Const: generated.EnumType + identifier(value), Value: value}
+			if err := declare(identifiers, enum.Const, fmt.Sprintf("value %q of property %q", value, property.Name)); err != nil {
+				return generated, err
+			}
+
+			generated.Enums = append(generated.Enums, enum)
+		}
+
+		generated.Type = generated.EnumType
+	}
+
+	value := "props." + generated.Field
+
+	switch {
+	case property.Required:
+	case generated.EnumType != "":
+		generated.Check = value + ` != ""`
+	case strings.HasPrefix(generated.Type, "[]") || strings.HasPrefix(generated.Type, "map[") || generated.Type == "interface{}":
+		generated.Check = value + " != nil"
+	default:
+		generated.Type = "*" + generated.Type
+		generated.Check = value + " != nil"
+		value = "*" + value
+	}
+
+	if generated.EnumType != "" {
+		value = "string(" + value + ")"
+	}
+
+	generated.Value = value
+
+	return generated, nil
+}
+
+// planLiteral returns the fields of a Plan composite literal, leaving out empty fields.
+func planLiteral(plan types.Plan) string {
+	var fields []string
+
+	for _, field := range []struct{ name, value string }{
+		{"Branch", plan.Branch},
+		{"Source", plan.Source},
+		{"Version", plan.Version},
+		{"VersionID", plan.VersionID},
+	} {
+		if field.value != "" {
+			fields = append(fields, fmt.Sprintf("%s: %q", field.name, field.value))
+		}
+	}
+
+	return strings.Join(fields, ", ")
+}
+
+// declare returns an error if the identifier is already declared for something else.
+func declare(identifiers map[string]string, identifier string, what string) error {
+	if identifier == "" || !token.IsIdentifier(identifier) {
+		return fmt.Errorf("%s has no letters for a Go name", what)
+	}
+
+	if declared, ok := identifiers[identifier]; ok {
+		return fmt.Errorf("%s and %s are both named %s in Go", what, declared, identifier)
+	}
+
+	identifiers[identifier] = what
+
+	return nil
+}
+
+// identifier converts a name to an exported Go identifier, e.g. "Song Played" and "song_played" to SongPlayed.
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+
+	for _, word := range words {
+		if _, ok := initialisms[strings.ToUpper(word)]; ok {
+			b.WriteString(strings.ToUpper(word))
+
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	result := b.String()
+	if result != "" && unicode.IsDigit([]rune(result)[0]) {
+		result = "N" + result
+	}
+
+	return result
+}
+
+var generatorTemplate = template.Must(template.New("").Parse(`// Code generated by ampligen{{if .Source}} from {{.Source}}{{end}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import "github.com/amplitude/analytics-go/amplitude"
+{{if .Plan}}
+// Plan is the version of the tracking plan the events are generated from.
+var Plan = amplitude.Plan{ {{- .Plan -}} }
+
+func newPlan() *amplitude.Plan {
+	plan := Plan
+
+	return &plan
+}
+{{end}}
+{{- range $event := .Events}}
+{{- range $property := .Properties}}{{if .EnumType}}
+// {{.EnumType}} is a value of the {{printf "%q" .Name}} property of the {{printf "%q" $event.Name}} event.
+type {{.EnumType}} string
+
+const (
+{{- range .Enums}}
+	{{.Const}} {{$property.EnumType}} = {{printf "%q" .Value}}
+{{- end}}
+)
+{{end}}{{end}}
+{{- if .PropsType}}
+// {{.PropsType}} are the properties of the {{printf "%q" .Name}} event, optional properties may be nil or empty.
+type {{.PropsType}} struct {
+{{- range .Properties}}
+	{{.Field}} {{.Type}}
+{{- end}}
+{{- if .Extra}}
+
+	// Extra are unplanned properties, planned properties take precedence.
+	Extra map[string]interface{}
+{{- end}}
+}
+{{end}}
+// {{.Func}} builds the {{printf "%q" .Name}} event for the user.
+func {{.Func}}(userID string{{if .PropsType}}, props {{.PropsType}}{{end}}) amplitude.Event {
+{{- if .PropsType}}
+	properties := make(map[string]interface{})
+{{- if .Extra}}
+	for name, value := range props.Extra {
+		properties[name] = value
+	}
+{{- end}}
+{{range .Properties}}
+{{- if .Check}}
+	if {{.Check}} {
+		properties[{{printf "%q" .Name}}] = {{.Value}}
+	}
+{{- else}}
+	properties[{{printf "%q" .Name}}] = {{.Value}}
+{{- end}}
+{{- end}}
+{{end}}
+	return amplitude.Event{
+		EventType:    {{printf "%q" .Name}},
+		EventOptions: amplitude.EventOptions{UserID: userID{{if $.Plan}}, Plan: newPlan(){{end}}},
+{{- if .PropsType}}
+		EventProperties: properties,
+{{- end}}
+	}
+}
+{{end}}`))
@@ -0,0 +1,119 @@
+package trackingplan_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/trackingplan"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestGenerator(t *testing.T) {
+	suite.Run(t, new(GeneratorSuite))
+}
+
+type GeneratorSuite struct {
+	suite.Suite
+}
+
+// TestGenerate_Example checks that the generated code of the example is up to date, run go generate ./examples/... to update it.
+func (t *GeneratorSuite) TestGenerate_Example() {
+	plan, err := trackingplan.Load("../../examples/ampli_example/tracking-plan.yaml")
+
+	require := t.Require()
+	require.NoError(err)
+
+	code, err := trackingplan.Generate(plan, trackingplan.GeneratorOptions{Package: "ampli", Source: "tracking-plan.yaml"})
+	require.NoError(err)
+
+	expected, err := os.ReadFile("../../examples/ampli_example/ampli/ampli.go")
+	require.NoError(err)
+	require.Equal(string(expected), string(code))
+}
+
+func (t *GeneratorSuite) TestGenerate_WithoutPlan() {
+	code, err := trackingplan.Generate(&types.TrackingPlan{
+		Events: []types.TrackingPlanEvent{{
+			Name: "user_signed_up",
+			Properties: []types.TrackingPlanProperty{
+				{Name: "referrer-url", Type: types.PropertyTypeString},
+				{Name: "3d", Type: types.PropertyTypeBoolean, Required: true},
+				{Name: "tags"},
+			},
+		}},
+	}, trackingplan.GeneratorOptions{Package: "events"})
+
+	require := t.Require()
+	require.NoError(err)
+	require.Equal(`// Code generated by ampligen. DO NOT EDIT.
+
+package events
+
+import "github.com/amplitude/analytics-go/amplitude"
+
+// UserSignedUpProps are the properties of the "user_signed_up" event, optional properties may be nil or empty.
+type UserSignedUpProps struct {
+	ReferrerURL *string
+	N3d         bool
+	Tags        interface{}
+}
+
+// UserSignedUp builds the "user_signed_up" event for the user.
+func UserSignedUp(userID string, props UserSignedUpProps) amplitude.Event {
+	properties := make(map[string]interface{})
+
+	if props.ReferrerURL != nil {
+		properties["referrer-url"] = *props.ReferrerURL
+	}
+	properties["3d"] = props.N3d
+	if props.Tags != nil {
+		properties["tags"] = props.Tags
+	}
+
+	return amplitude.Event{
+		EventType:       "user_signed_up",
+		EventOptions:    amplitude.EventOptions{UserID: userID},
+		EventProperties: properties,
+	}
+}
+`, string(code))
+}
+
+func (t *GeneratorSuite) TestGenerate_Invalid() {
+	tests := []struct {
+		plan    types.TrackingPlan
+		options trackingplan.GeneratorOptions
+		err     string
+	}{
+		{
+			options: trackingplan.GeneratorOptions{Package: "my-events"},
+			err:     `invalid package name "my-events"`,
+		},
+		{
+			plan:    types.TrackingPlan{Events: []types.TrackingPlanEvent{{Name: "Song Played"}, {Name: "song_played"}}},
+			options: trackingplan.GeneratorOptions{Package: "ampli"},
+			err:     `event "song_played" and event "Song Played" are both named SongPlayed in Go`,
+		},
+		{
+			plan:    types.TrackingPlan{Events: []types.TrackingPlanEvent{{Name: "$$"}}},
+			options: trackingplan.GeneratorOptions{Package: "ampli"},
+			err:     `event "$$" has no letters for a Go name`,
+		},
+		{
+			plan: types.TrackingPlan{Events: []types.TrackingPlanEvent{{
+				Name:       "Song Played",
+				Properties: []types.TrackingPlanProperty{{Name: "song id"}, {Name: "song_id"}},
+			}}},
+			options: trackingplan.GeneratorOptions{Package: "ampli"},
+			err:     `event "Song Played": property "song_id" and property "song id" are both named SongID in Go`,
+		},
+	}
+
+	for _, tt := range tests {
+		plan := tt.plan
+		_, err := trackingplan.Generate(&plan, tt.options)
+		t.Require().EqualError(err, tt.err)
+	}
+}
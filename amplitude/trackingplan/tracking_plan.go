@@ -0,0 +1,86 @@
+package trackingplan
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+var propertyTypes = map[types.PropertyType]struct{}{
+	"":                        {},
+	types.PropertyTypeAny:     {},
+	types.PropertyTypeString:  {},
+	types.PropertyTypeNumber:  {},
+	types.PropertyTypeInteger: {},
+	types.PropertyTypeBoolean: {},
+	types.PropertyTypeArray:   {},
+	types.PropertyTypeObject:  {},
+}
+
+// Load reads a tracking plan from a JSON or YAML file.
+func Load(path string) (*types.TrackingPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return plan, nil
+}
+
+// Parse parses a tracking plan in JSON or YAML, YAML being a superset of JSON.
+// It returns an error if events or properties are unnamed, duplicated or have an unknown type.
+func Parse(data []byte) (*types.TrackingPlan, error) {
+	var plan types.TrackingPlan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+
+	if err := validatePlan(&plan); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+func validatePlan(plan *types.TrackingPlan) error {
+	eventNames := make(map[string]struct{}, len(plan.Events))
+
+	for _, event := range plan.Events {
+		if event.Name == "" {
+			return fmt.Errorf("tracking plan has an unnamed event")
+		}
+
+		if _, ok := eventNames[event.Name]; ok {
+			return fmt.Errorf("tracking plan has duplicate event %q", event.Name)
+		}
+
+		eventNames[event.Name] = struct{}{}
+		propertyNames := make(map[string]struct{}, len(event.Properties))
+
+		for _, property := range event.Properties {
+			if property.Name == "" {
+				return fmt.Errorf("event %q has an unnamed property", event.Name)
+			}
+
+			if _, ok := propertyNames[property.Name]; ok {
+				return fmt.Errorf("event %q has duplicate property %q", event.Name, property.Name)
+			}
+
+			propertyNames[property.Name] = struct{}{}
+
+			if _, ok := propertyTypes[property.Type]; !ok {
+				return fmt.Errorf("property %q of event %q has unknown type %q", property.Name, event.Name, property.Type)
+			}
+		}
+	}
+
+	return nil
+}
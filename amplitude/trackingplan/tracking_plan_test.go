@@ -0,0 +1,106 @@
+package trackingplan_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/trackingplan"
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestTrackingPlan(t *testing.T) {
+	suite.Run(t, new(TrackingPlanSuite))
+}
+
+type TrackingPlanSuite struct {
+	suite.Suite
+}
+
+var expectedPlan = &types.TrackingPlan{
+	Plan: types.Plan{Branch: "main", Version: "3", VersionID: "version-3"},
+	Events: []types.TrackingPlanEvent{
+		{
+			Name: "Song Played",
+			Properties: []types.TrackingPlanProperty{
+				{Name: "genre", Type: types.PropertyTypeString, Required: true, Enum: []interface{}{"rock", "jazz"}},
+				{Name: "duration", Type: types.PropertyTypeNumber},
+			},
+		},
+		{Name: "App Opened", AllowUnplannedProperties: true},
+	},
+}
+
+func (t *TrackingPlanSuite) TestParse_JSON() {
+	plan, err := trackingplan.Parse([]byte(`{
+		"branch": "main",
+		"version": "3",
+		"versionId": "version-3",
+		"events": [
+			{
+				"name": "Song Played",
+				"properties": [
+					{"name": "genre", "type": "string", "required": true, "enum": ["rock", "jazz"]},
+					{"name": "duration", "type": "number"}
+				]
+			},
+			{"name": "App Opened", "allowUnplannedProperties": true}
+		]
+	}`))
+
+	require := t.Require()
+	require.NoError(err)
+	require.Equal(expectedPlan, plan)
+}
+
+func (t *TrackingPlanSuite) TestLoad_YAML() {
+	path := filepath.Join(t.T().TempDir(), "plan.yaml")
+
+	require := t.Require()
+	require.NoError(os.WriteFile(path, []byte(`
+branch: main
+version: "3"
+versionId: version-3
+events:
+  - name: Song Played
+    properties:
+      - name: genre
+        type: string
+        required: true
+        enum: [rock, jazz]
+      - name: duration
+        type: number
+  - name: App Opened
+    allowUnplannedProperties: true
+`), 0o600))
+
+	plan, err := trackingplan.Load(path)
+	require.NoError(err)
+	require.Equal(expectedPlan, plan)
+
+	_, err = trackingplan.Load(filepath.Join(t.T().TempDir(), "missing.yaml"))
+	require.Error(err)
+}
+
+func (t *TrackingPlanSuite) TestParse_Invalid() {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{`{"events": [{"name": ""}]}`, "tracking plan has an unnamed event"},
+		{`{"events": [{"name": "A"}, {"name": "A"}]}`, `tracking plan has duplicate event "A"`},
+		{`{"events": [{"name": "A", "properties": [{"name": ""}]}]}`, `event "A" has an unnamed property`},
+		{`{"events": [{"name": "A", "properties": [{"name": "p"}, {"name": "p"}]}]}`, `event "A" has duplicate property "p"`},
+		{`{"events": [{"name": "A", "properties": [{"name": "p", "type": "date"}]}]}`, `property "p" of event "A" has unknown type "date"`},
+	}
+
+	for _, tt := range tests {
+		_, err := trackingplan.Parse([]byte(tt.data))
+		t.Require().EqualError(err, tt.err)
+	}
+
+	_, err := trackingplan.Parse([]byte(`{"events": `))
+	t.Require().Error(err)
+}
@@ -0,0 +1,16 @@
+package types
+
+// BackoffStrategy selects how the retry interval of events grows after timeouts and server errors.
+type BackoffStrategy int
+
+const (
+	// BackoffExponential doubles Config.RetryBaseInterval every second retry.
+	BackoffExponential BackoffStrategy = iota
+
+	// BackoffFullJitter picks a random interval up to the exponential one.
+	BackoffFullJitter
+
+	// BackoffDecorrelatedJitter picks a random interval between Config.RetryBaseInterval
+	// and three times the previous interval of the event.
+	BackoffDecorrelatedJitter
+)
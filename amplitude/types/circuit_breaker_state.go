@@ -0,0 +1,28 @@
+package types
+
+// CircuitBreakerState is the state of the circuit breaker of the Amplitude destination.
+type CircuitBreakerState int
+
+const (
+	// CircuitBreakerClosed sends events as usual.
+	CircuitBreakerClosed CircuitBreakerState = iota
+
+	// CircuitBreakerOpen keeps events in storage after consecutive failed requests.
+	CircuitBreakerOpen
+
+	// CircuitBreakerHalfOpen sends a single probe request, it closes the circuit if it succeeds.
+	CircuitBreakerHalfOpen
+)
+
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case CircuitBreakerClosed:
+		return "closed"
+	case CircuitBreakerOpen:
+		return "open"
+	case CircuitBreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,9 @@
+package types
+
+// Compressor compresses request bodies of HTTP uploads, see Config.Compressor.
+// The compression package provides gzip, other encodings such as zstd can be plugged in by implementing it.
+type Compressor interface {
+	// ContentEncoding is the value of the Content-Encoding header, e.g. "gzip".
+	ContentEncoding() string
+	Compress(data []byte) ([]byte, error)
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -24,6 +25,101 @@ type Config struct {
 	MaxStorageCapacity     int
 	RetryBaseInterval      time.Duration
 	RetryThrottledInterval time.Duration
+
+	// RetryBackoffStrategy selects how retry intervals grow, RetryMaxInterval caps them if set.
+	// A Retry-After header of the response takes precedence over both.
+	RetryBackoffStrategy BackoffStrategy
+	RetryMaxInterval     time.Duration
+
+	// QueueFullPolicy selects what happens to events tracked while the queue of MaxStorageCapacity is full.
+	// Dropped events are reported through ExecuteCallback.
+	QueueFullPolicy       QueueFullPolicy
+	QueueFullBlockTimeout time.Duration
+
+	// StorageLeaseTimeout is how long events leased from a LeasingEventStorage stay reserved
+	// while being sent. It should be longer than ConnectionTimeout.
+	StorageLeaseTimeout time.Duration
+
+	// FlushMaxPayloadBytes caps the bytes of a request, events are split into requests by their encoded size.
+	// The payload limit of the endpoint applies if it's 0 or larger. A chunk size reduced by 413 responses
+	// grows back one step after FlushSizeRecoverAfter successful requests in a row.
+	FlushMaxPayloadBytes  int
+	FlushSizeRecoverAfter int
+
+	// FlushWorkers is the number of chunks sent to ServerURL in parallel, 0 or 1 sends chunks one by one.
+	// Events are partitioned by user ID, or device ID without user ID, so events of a user keep their order.
+	FlushWorkers int
+
+	// AutoBatch sends events to BatchServerURL while at least AutoBatchBacklog events wait in storage,
+	// and for AutoBatchDuration after a response reports an exceeded daily quota, which is higher for the batch endpoint.
+	// Events over the daily quota are then retried after Retry-After or RetryThrottledInterval instead of being dropped.
+	// It has no effect with UseBatch.
+	// BatchServerURL defaults to the batch endpoint of ServerZone unless ServerURL is set to a custom URL,
+	// e.g. a proxy. AutoBatch then always sends events to ServerURL, unless BatchServerURL is set as well.
+	AutoBatch         bool
+	AutoBatchBacklog  int
+	AutoBatchDuration time.Duration
+	BatchServerURL    string
+
+	// Compressor compresses request bodies of HTTP uploads, see the compression package.
+	// Compression is disabled if the server rejects compressed request bodies.
+	Compressor Compressor
+
+	// HTTPClient sends requests to ServerURL if set, then ConnectionTimeout and HTTPTransport are ignored.
+	// HTTPTransport is the transport of the default HTTP client, e.g. for proxies, mTLS or connection pool sizing.
+	// HTTPHeaders are added to every request, Content-Type and Content-Encoding are set by the SDK.
+	HTTPClient    *http.Client
+	HTTPTransport http.RoundTripper
+	HTTPHeaders   http.Header
+
+	// CircuitBreakerThreshold is the number of consecutive failed requests that stop sending events
+	// for CircuitBreakerOpenTimeout, events are kept in storage meanwhile. 0 disables the circuit breaker.
+	CircuitBreakerThreshold   int
+	CircuitBreakerOpenTimeout time.Duration
+
+	// CircuitBreakerCallback is called with the new state whenever the circuit breaker changes state.
+	// The state is also reported by the amplitude_circuit_breaker_state metric.
+	CircuitBreakerCallback func(state CircuitBreakerState)
+
+	// TruncateEvents truncates properties of events to MaxStringLength and MaxPropertyKeys of constants,
+	// MaxArrayLength items and MaxPropertyDepth nesting levels before they are sent, instead of having them rejected.
+	// Truncations are added to the message of ExecuteResult. A single event rejected as too large
+	// is truncated to half the limits and retried.
+	TruncateEvents   bool
+	MaxArrayLength   int
+	MaxPropertyDepth int
+
+	// PreserveUserEventOrder holds back events of a user or device while its earlier events wait for a retry,
+	// e.g. so an identify is never sent after a later track. It applies to events kept in memory since Setup.
+	PreserveUserEventOrder bool
+
+	// CoalesceIdentifies merges identify events of a user or device sent in the same chunk into one event,
+	// if no other event of the user is between them and their operations combine to the same user profile.
+	// Identify events are only merged within a chunk pulled from storage, not across flushes,
+	// and events waiting for a retry are never merged.
+	// Every merged event is still reported through ExecuteCallback. It has no effect with a LeasingEventStorage,
+	// e.g. the one of storages.NewFileEventStorage, since leased events can't be replaced by a merged event.
+	CoalesceIdentifies bool
+
+	// StickyGroups remembers the groups of a user or device changed by SetGroup, AddToGroup and RemoveFromGroup,
+	// and adds them to Groups of later events of the user, groups of the event take precedence.
+	// Identify and group identify events are left as they are. Groups of at most StickyGroupsMaxUsers users are kept,
+	// 10000 if it's not positive.
+	StickyGroups         bool
+	StickyGroupsMaxUsers int
+
+	// CurrencyConverter converts the amounts of revenue events in another currency to ReportingCurrency.
+	// The original currency and amounts are kept in event properties. Revenue that fails to be converted,
+	// or has no Currency, is sent as it is. Currency codes are compared in upper case.
+	CurrencyConverter CurrencyConverter
+	ReportingCurrency string
+
+	// Metrics receives metrics of the SDK pipeline, see the metrics package.
+	Metrics Metrics
+
+	// TracerProvider receives spans of tracked events and batch uploads, see the tracing package,
+	// and the tracing/otel module for OpenTelemetry.
+	TracerProvider TracerProvider
 }
 
 func NewConfig(apiKey string) Config {
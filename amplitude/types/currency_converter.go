@@ -0,0 +1,7 @@
+package types
+
+// CurrencyConverter converts revenue amounts to Config.ReportingCurrency, see the currency package.
+// Currencies are ISO 4217 codes, e.g. "EUR".
+type CurrencyConverter interface {
+	Convert(amount float64, from string, to string) (float64, error)
+}
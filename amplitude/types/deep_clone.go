@@ -0,0 +1,95 @@
+package types
+
+import (
+	"reflect"
+)
+
+// deepClone copies pointers, slices, arrays, maps and exported struct fields of a value recursively,
+// so no part of the clone is shared with the original. Unexported struct fields are copied as they are.
+// A pointer reached twice is cloned once, which keeps cycles and shared parts of the value.
+func deepClone(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	return cloneValue(reflect.ValueOf(value), make(map[clonedPointer]reflect.Value)).Interface()
+}
+
+// clonedPointer identifies a pointer by its type too, as a pointer to a struct and its first field are equal.
+type clonedPointer struct {
+	address uintptr
+	typ     reflect.Type
+}
+
+func cloneValue(v reflect.Value, pointers map[clonedPointer]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		key := clonedPointer{address: v.Pointer(), typ: v.Type()}
+		if clone, ok := pointers[key]; ok {
+			return clone
+		}
+
+		clone := reflect.New(v.Type().Elem())
+		pointers[key] = clone
+		clone.Elem().Set(cloneValue(v.Elem(), pointers))
+
+		return clone
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		clone := reflect.New(v.Type()).Elem()
+		clone.Set(cloneValue(v.Elem(), pointers))
+
+		return clone
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		clone := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(cloneValue(v.Index(i), pointers))
+		}
+
+		return clone
+	case reflect.Array:
+		clone := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			clone.Index(i).Set(cloneValue(v.Index(i), pointers))
+		}
+
+		return clone
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		clone := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneValue(iter.Value(), pointers))
+		}
+
+		return clone
+	case reflect.Struct:
+		clone := reflect.New(v.Type()).Elem()
+		clone.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			if clone.Field(i).CanSet() {
+				clone.Field(i).Set(cloneValue(v.Field(i), pointers))
+			}
+		}
+
+		return clone
+	}
+
+	return v
+}
@@ -0,0 +1,18 @@
+package types
+
+// Endpoint is the Amplitude API events are uploaded to.
+type Endpoint string
+
+const (
+	// EndpointHTTPAPI is the HTTP API v2.
+	EndpointHTTPAPI Endpoint = "httpapi"
+
+	// EndpointBatch is the Batch Event Upload API, it has higher limits for bulk uploads.
+	EndpointBatch Endpoint = "batch"
+)
+
+// EndpointLimits are the limits of a single request to an Endpoint.
+type EndpointLimits struct {
+	MaxEvents       int
+	MaxPayloadBytes int
+}
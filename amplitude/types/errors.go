@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrClientOptedOut is returned for events tracked while the client is opted out.
+	ErrClientOptedOut = errors.New("amplitude: client is opted out")
+
+	// ErrClientShutdown is returned for events tracked after the client was shut down.
+	ErrClientShutdown = errors.New("amplitude: client is shut down")
+
+	// ErrQueueFull is returned when a destination has no room for the event.
+	ErrQueueFull = errors.New("amplitude: event queue is full")
+
+	// ErrEventFiltered is returned when a before or enrichment plugin drops the event.
+	ErrEventFiltered = errors.New("amplitude: event was filtered out by a plugin")
+
+	// ErrInvalidEvent is wrapped by every ValidationError.
+	ErrInvalidEvent = errors.New("amplitude: invalid event")
+)
+
+// ValidationError is returned when an event, Identify or Revenue fails validation.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return ErrInvalidEvent.Error() + ": " + strings.Join(e.Errors, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidEvent
+}
@@ -149,6 +149,6 @@ func cloneUnknown(value interface{}) interface{} {
 
 		return clone
 	default:
-		return value
+		return deepClone(value)
 	}
 }
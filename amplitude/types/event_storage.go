@@ -9,9 +9,41 @@ type EventStorage interface {
 	Count(before time.Time) int
 }
 
+// LeasingEventStorage is an EventStorage that keeps pulled events until their delivery is acknowledged.
+// Unlike Pull, events taken with Lease are not lost if the process crashes while they are being sent.
+type LeasingEventStorage interface {
+	EventStorage
+
+	// Lease returns a chunk of events reserved until they are acknowledged.
+	// Events not acknowledged within the timeout are made available again.
+	Lease(count int, before time.Time, timeout time.Duration) *EventLease
+
+	// Ack removes delivered events of the lease from the storage.
+	Ack(lease *EventLease, events ...*StorageEvent)
+
+	// Nack returns events of the lease back to the storage, keeping their RetryAt and RetryCount.
+	Nack(lease *EventLease, events ...*StorageEvent)
+}
+
 type StorageEvent struct {
 	*Event
 
 	RetryAt    time.Time
 	RetryCount int
+
+	// RetryInterval is the interval the event waited before RetryAt.
+	RetryInterval time.Duration
+
+	// Truncations describe how properties of the event were truncated to be accepted.
+	Truncations []string
+
+	// CoalescedEvents are the identify events merged into this one, they are reported with its result instead of it.
+	// Merged events are only created for storages that aren't a LeasingEventStorage, so it isn't persisted.
+	CoalescedEvents []*Event
+}
+
+type EventLease struct {
+	ID        uint64
+	Events    []*StorageEvent
+	ExpiresAt time.Time
 }
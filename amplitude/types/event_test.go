@@ -113,3 +113,52 @@ func (t *EventSuite) TestClone() {
 	require.NotSame(original.GroupProperties[types.IdentityOpAdd], clone.GroupProperties[types.IdentityOpAdd])
 	require.NotSame(original.GroupProperties[types.IdentityOpClearAll], clone.GroupProperties[types.IdentityOpClearAll])
 }
+
+func (t *EventSuite) TestClone_Reflect() {
+	type album struct {
+		Title   string
+		Tracks  []string
+		Artist  *string
+		Related *album
+	}
+
+	artist := "artist-A"
+	original := types.Event{
+		EventType: "my-event",
+		EventProperties: map[string]interface{}{
+			"prop-struct":       album{Title: "album-A", Tracks: []string{"track-A"}, Artist: &artist},
+			"prop-pointer":      &album{Title: "album-B"},
+			"prop-array-map":    []map[string]interface{}{{"prop-1": []int{1}}},
+			"prop-map-pointers": map[string]*album{"album": {Title: "album-C"}},
+			"prop-array":        [2][]string{{"string"}, {"value"}},
+		},
+	}
+
+	cyclic := &album{Title: "album-D"}
+	cyclic.Related = cyclic
+	original.EventProperties["prop-cyclic"] = cyclic
+
+	clone := original.Clone()
+
+	require := t.Require()
+	require.Equal(original, clone)
+
+	clonedStruct := clone.EventProperties["prop-struct"].(album)
+	clonedStruct.Tracks[0] = "changed"
+	*clonedStruct.Artist = "changed"
+	clone.EventProperties["prop-pointer"].(*album).Title = "changed"
+	clone.EventProperties["prop-array-map"].([]map[string]interface{})[0]["prop-1"].([]int)[0] = 2
+	clone.EventProperties["prop-map-pointers"].(map[string]*album)["album"].Title = "changed"
+	clone.EventProperties["prop-array"].([2][]string)[0][0] = "changed"
+
+	require.Equal("track-A", original.EventProperties["prop-struct"].(album).Tracks[0])
+	require.Equal("artist-A", artist)
+	require.Equal("album-B", original.EventProperties["prop-pointer"].(*album).Title)
+	require.Equal([]int{1}, original.EventProperties["prop-array-map"].([]map[string]interface{})[0]["prop-1"])
+	require.Equal("album-C", original.EventProperties["prop-map-pointers"].(map[string]*album)["album"].Title)
+	require.Equal("string", original.EventProperties["prop-array"].([2][]string)[0][0])
+
+	clonedCyclic := clone.EventProperties["prop-cyclic"].(*album)
+	require.NotSame(cyclic, clonedCyclic)
+	require.Same(clonedCyclic, clonedCyclic.Related)
+}
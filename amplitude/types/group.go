@@ -0,0 +1,49 @@
+package types
+
+import "fmt"
+
+// maxGroupLength is the length of a group type or name accepted by the server.
+const maxGroupLength = 1024
+
+// ValidateGroup returns errors if the group type or a group name can't be sent.
+// Group types starting with "$" are reserved for Amplitude properties.
+func ValidateGroup(groupType string, groupNames []string) []string {
+	var validateErrors []string
+
+	switch {
+	case groupType == "":
+		validateErrors = append(validateErrors, "Group type is empty")
+	case groupType[0] == '$':
+		validateErrors = append(validateErrors, fmt.Sprintf("Group type %s is reserved", groupType))
+	case len(groupType) > maxGroupLength:
+		validateErrors = append(validateErrors, fmt.Sprintf("Group type is longer than %d bytes", maxGroupLength))
+	}
+
+	if len(groupNames) == 0 {
+		validateErrors = append(validateErrors, "Group names are empty")
+	}
+
+	seen := make(map[string]struct{}, len(groupNames))
+
+	for i, groupName := range groupNames {
+		if groupName == "" {
+			validateErrors = append(validateErrors, fmt.Sprintf("Group name at index %d is empty", i))
+
+			continue
+		}
+
+		if len(groupName) > maxGroupLength {
+			validateErrors = append(validateErrors, fmt.Sprintf("Group name at index %d is longer than %d bytes", i, maxGroupLength))
+
+			continue
+		}
+
+		if _, ok := seen[groupName]; ok {
+			validateErrors = append(validateErrors, fmt.Sprintf("Group name %s is duplicated", groupName))
+		}
+
+		seen[groupName] = struct{}{}
+	}
+
+	return validateErrors
+}
@@ -0,0 +1,25 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestValidateGroup(t *testing.T) {
+	require.Empty(t, types.ValidateGroup("workspace", []string{"acme", "globex"}))
+
+	require.Equal(t, []string{"Group type is empty", "Group names are empty"}, types.ValidateGroup("", nil))
+	require.Equal(t, []string{"Group type $os is reserved"}, types.ValidateGroup("$os", []string{"acme"}))
+	require.Equal(t, []string{"Group type is longer than 1024 bytes"},
+		types.ValidateGroup(strings.Repeat("a", 1025), []string{"acme"}))
+
+	require.Equal(t, []string{
+		"Group name at index 1 is empty",
+		"Group name at index 2 is longer than 1024 bytes",
+		"Group name acme is duplicated",
+	}, types.ValidateGroup("workspace", []string{"acme", "", strings.Repeat("a", 1025), "acme"}))
+}
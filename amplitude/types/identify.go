@@ -1,7 +1,11 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 type IdentityOp string
@@ -21,19 +25,100 @@ const (
 	UnsetValue string = "-"
 )
 
+// IdentifyIssueCode identifies a problem of an Identify operation.
+type IdentifyIssueCode string
+
+const (
+	IdentifyIssueEmptyProperties   IdentifyIssueCode = "empty_properties"
+	IdentifyIssueEmptyProperty     IdentifyIssueCode = "empty_property"
+	IdentifyIssueNilValue          IdentifyIssueCode = "nil_value"
+	IdentifyIssueInvalidValue      IdentifyIssueCode = "invalid_value"
+	IdentifyIssueDuplicateProperty IdentifyIssueCode = "duplicate_property"
+	IdentifyIssueAfterClearAll     IdentifyIssueCode = "after_clear_all"
+	IdentifyIssueClearedByClearAll IdentifyIssueCode = "cleared_by_clear_all"
+)
+
+// IdentifyIssue is an operation of an Identify that was ignored or removed, or an error of the whole Identify.
+type IdentifyIssue struct {
+	Code      IdentifyIssueCode
+	Operation IdentityOp
+	Property  string
+	Message   string
+}
+
+// IdentifyValidation lists the issues of an Identify, it can't be sent if there are errors.
+type IdentifyValidation struct {
+	Errors   []IdentifyIssue
+	Warnings []IdentifyIssue
+}
+
+func (v IdentifyValidation) IsValid() bool {
+	return len(v.Errors) == 0
+}
+
+// Identify collects operations on user or group properties. The rules of the server are applied as operations are added:
+// an operation on a property that already has one is ignored, $clearAll removes previous operations and
+// makes later ones ignored, $add only takes numbers, and array operations don't take objects.
+// Ignored operations are reported as warnings of Validation.
 type Identify struct {
-	PropertiesSet    map[string]struct{}
-	Properties       map[IdentityOp]map[string]interface{}
-	validateWarnings []string
+	PropertiesSet map[string]struct{}
+	Properties    map[IdentityOp]map[string]interface{}
+	warnings      []IdentifyIssue
 }
 
 func (i *Identify) Validate() ([]string, []string) {
-	var validateErrors []string
+	validation := i.Validation()
+
+	return issueMessages(validation.Errors), issueMessages(validation.Warnings)
+}
+
+// Validation returns the issues of the operations added so far.
+func (i *Identify) Validation() IdentifyValidation {
+	validation := IdentifyValidation{Warnings: i.warnings}
 	if len(i.Properties) == 0 {
-		validateErrors = append(validateErrors, "Empty Properties")
+		validation.Errors = append(validation.Errors, IdentifyIssue{
+			Code:    IdentifyIssueEmptyProperties,
+			Message: "Empty Properties",
+		})
 	}
 
-	return validateErrors, i.validateWarnings
+	return validation
+}
+
+// Merge adds the operations of other as if they were added to i after its own operations,
+// so operations of i take precedence unless other contains $clearAll. Warnings of other are kept.
+func (i *Identify) Merge(other Identify) *Identify {
+	i.warnings = append(i.warnings, other.warnings...)
+
+	if other.containsOperation(IdentityOpClearAll) {
+		i.ClearAll()
+	}
+
+	operations := make([]IdentityOp, 0, len(other.Properties))
+	for operation := range other.Properties {
+		if operation != IdentityOpClearAll {
+			operations = append(operations, operation)
+		}
+	}
+
+	sort.Slice(operations, func(a, b int) bool {
+		return operations[a] < operations[b]
+	})
+
+	for _, operation := range operations {
+		properties := make([]string, 0, len(other.Properties[operation]))
+		for property := range other.Properties[operation] {
+			properties = append(properties, property)
+		}
+
+		sort.Strings(properties)
+
+		for _, property := range properties {
+			i.setUserProperty(operation, property, other.Properties[operation][property])
+		}
+	}
+
+	return i
 }
 
 func (i *Identify) containsProperty(property string) bool {
@@ -43,30 +128,48 @@ func (i *Identify) containsProperty(property string) bool {
 }
 
 func (i *Identify) containsOperation(op IdentityOp) bool {
-	for operation := range i.Properties {
-		if operation == op {
-			return true
-		}
-	}
+	_, ok := i.Properties[op]
 
-	return false
+	return ok
 }
 
-func (i *Identify) setUserProperty(operation IdentityOp, property string, value interface{}) {
-	if len(property) == 0 {
-		i.validateWarnings = append(i.validateWarnings, fmt.Sprintf("Attempting to perform operation %s with a null or empty string property, ignoring", string(operation)))
-	}
+func (i *Identify) warn(code IdentifyIssueCode, operation IdentityOp, property string, message string) {
+	i.warnings = append(i.warnings, IdentifyIssue{Code: code, Operation: operation, Property: property, Message: message})
+}
 
-	if value == nil && operation != IdentityOpClearAll {
-		i.validateWarnings = append(i.validateWarnings, fmt.Sprintf("Attempting to perform operation %s with null value for property %s, ignoring", string(operation), property))
+func (i *Identify) setUserProperty(operation IdentityOp, property string, value interface{}) {
+	switch {
+	case operation == IdentityOpClearAll:
+		i.clearAll()
+
+		return
+	case len(property) == 0:
+		i.warn(IdentifyIssueEmptyProperty, operation, property,
+			fmt.Sprintf("Attempting to perform operation %s with a null or empty string property, ignoring", string(operation)))
+
+		return
+	case value == nil:
+		i.warn(IdentifyIssueNilValue, operation, property,
+			fmt.Sprintf("Attempting to perform operation %s with null value for property %s, ignoring", string(operation), property))
+
+		return
+	case i.containsOperation(IdentityOpClearAll):
+		i.warn(IdentifyIssueAfterClearAll, operation, property,
+			fmt.Sprintf("This Identify already contains a $clearAll operation, ignoring operation %s for property %s", string(operation), property))
+
+		return
+	case i.containsProperty(property):
+		i.warn(IdentifyIssueDuplicateProperty, operation, property,
+			fmt.Sprintf("Already used property %s in previous operation, ignoring operation %s", property, string(operation)))
+
+		return
 	}
 
-	if i.containsOperation(IdentityOpClearAll) {
-		i.validateWarnings = append(i.validateWarnings, fmt.Sprintf("This Identify already contains a $clearAll operation, ignoring operation %s for property %s", string(operation), property))
-	}
+	if problem := identifyValueProblem(operation, value); problem != "" {
+		i.warn(IdentifyIssueInvalidValue, operation, property,
+			fmt.Sprintf("Operation %s for property %s %s, ignoring", string(operation), property, problem))
 
-	if i.containsProperty(property) {
-		i.validateWarnings = append(i.validateWarnings, fmt.Sprintf("Already used property %s in previous operation, ignoring operation %s", property, string(operation)))
+		return
 	}
 
 	if i.Properties == nil {
@@ -85,6 +188,89 @@ func (i *Identify) setUserProperty(operation IdentityOp, property string, value
 	i.PropertiesSet[property] = struct{}{}
 }
 
+// clearAll replaces previous operations with $clearAll.
+func (i *Identify) clearAll() {
+	var properties []string
+
+	for operation, values := range i.Properties {
+		if operation == IdentityOpClearAll {
+			continue
+		}
+
+		for property := range values {
+			properties = append(properties, property)
+		}
+	}
+
+	if len(properties) > 0 {
+		sort.Strings(properties)
+		i.warn(IdentifyIssueClearedByClearAll, IdentityOpClearAll, "",
+			fmt.Sprintf("Operation $clearAll removes previous operations for properties %s", strings.Join(properties, ", ")))
+	}
+
+	i.Properties = map[IdentityOp]map[string]interface{}{
+		IdentityOpClearAll: {UnsetValue: nil},
+	}
+	i.PropertiesSet = make(map[string]struct{})
+}
+
+// identifyValueProblem describes why the value can't be used with the operation, or returns an empty string.
+func identifyValueProblem(operation IdentityOp, value interface{}) string {
+	if _, err := json.Marshal(value); err != nil {
+		return "has a value that can't be encoded"
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return "has a nil value"
+	}
+
+	switch operation {
+	case IdentityOpAdd:
+		if !isNumberValue(v) {
+			return fmt.Sprintf("expects a number, got %T", value)
+		}
+	case IdentityOpAppend, IdentityOpPrepend, IdentityOpPreInsert, IdentityOpPostInsert, IdentityOpRemove:
+		if isObjectValue(v) {
+			return fmt.Sprintf("expects a value or an array, got %T", value)
+		}
+	}
+
+	return ""
+}
+
+func isNumberValue(v reflect.Value) bool {
+	if _, ok := v.Interface().(json.Number); ok {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+func isObjectValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+}
+
+func issueMessages(issues []IdentifyIssue) []string {
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+
+	return messages
+}
+
 // Set sets the value of a user property.
 func (i *Identify) Set(property string, value interface{}) *Identify {
 	i.setUserProperty(IdentityOpSet, property, value)
@@ -168,6 +354,7 @@ func (i *Identify) Unset(property string) *Identify {
 }
 
 // ClearAll removes all user properties of this user.
+// It replaces previous operations of this Identify, and later operations are ignored.
 func (i *Identify) ClearAll() *Identify {
 	i.setUserProperty(IdentityOpClearAll, UnsetValue, nil)
 
@@ -0,0 +1,171 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestIdentify(t *testing.T) {
+	suite.Run(t, new(IdentifySuite))
+}
+
+type IdentifySuite struct {
+	suite.Suite
+}
+
+func (t *IdentifySuite) TestOperations() {
+	identify := types.Identify{}
+	identify.Set("name", "user-A").
+		SetOnce("signup", "2023-01-01").
+		Add("visits", 1).
+		Append("tags", []string{"a", "b"}).
+		Prepend("history", "c").
+		PreInsert("first", "d").
+		PostInsert("last", "e").
+		Remove("old", "f").
+		Unset("temp")
+
+	require := t.Require()
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet:        {"name": "user-A"},
+		types.IdentityOpSetOnce:    {"signup": "2023-01-01"},
+		types.IdentityOpAdd:        {"visits": 1},
+		types.IdentityOpAppend:     {"tags": []string{"a", "b"}},
+		types.IdentityOpPrepend:    {"history": "c"},
+		types.IdentityOpPreInsert:  {"first": "d"},
+		types.IdentityOpPostInsert: {"last": "e"},
+		types.IdentityOpRemove:     {"old": "f"},
+		types.IdentityOpUnset:      {"temp": types.UnsetValue},
+	}, identify.Properties)
+
+	validation := identify.Validation()
+	require.True(validation.IsValid())
+	require.Empty(validation.Warnings)
+}
+
+func (t *IdentifySuite) TestIgnoredOperations() {
+	var nilPointer *int
+
+	identify := types.Identify{}
+	identify.Set("name", "user-A").
+		Unset("name").
+		Set("", "value").
+		Set("empty", nil).
+		Set("channel", make(chan int)).
+		Add("visits", "1").
+		Add("missing", nilPointer).
+		Append("tags", map[string]interface{}{"a": 1}).
+		Add("score", 1.5)
+
+	require := t.Require()
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet: {"name": "user-A"},
+		types.IdentityOpAdd: {"score": 1.5},
+	}, identify.Properties)
+
+	require.Equal(types.IdentifyValidation{Warnings: []types.IdentifyIssue{
+		{
+			Code:      types.IdentifyIssueDuplicateProperty,
+			Operation: types.IdentityOpUnset,
+			Property:  "name",
+			Message:   "Already used property name in previous operation, ignoring operation $unset",
+		},
+		{
+			Code:      types.IdentifyIssueEmptyProperty,
+			Operation: types.IdentityOpSet,
+			Message:   "Attempting to perform operation $set with a null or empty string property, ignoring",
+		},
+		{
+			Code:      types.IdentifyIssueNilValue,
+			Operation: types.IdentityOpSet,
+			Property:  "empty",
+			Message:   "Attempting to perform operation $set with null value for property empty, ignoring",
+		},
+		{
+			Code:      types.IdentifyIssueInvalidValue,
+			Operation: types.IdentityOpSet,
+			Property:  "channel",
+			Message:   "Operation $set for property channel has a value that can't be encoded, ignoring",
+		},
+		{
+			Code:      types.IdentifyIssueInvalidValue,
+			Operation: types.IdentityOpAdd,
+			Property:  "visits",
+			Message:   "Operation $add for property visits expects a number, got string, ignoring",
+		},
+		{
+			Code:      types.IdentifyIssueInvalidValue,
+			Operation: types.IdentityOpAdd,
+			Property:  "missing",
+			Message:   "Operation $add for property missing has a nil value, ignoring",
+		},
+		{
+			Code:      types.IdentifyIssueInvalidValue,
+			Operation: types.IdentityOpAppend,
+			Property:  "tags",
+			Message:   "Operation $append for property tags expects a value or an array, got map[string]interface {}, ignoring",
+		},
+	}}, identify.Validation())
+}
+
+func (t *IdentifySuite) TestClearAll() {
+	identify := types.Identify{}
+	identify.Set("name", "user-A").Add("visits", 1).ClearAll().Set("plan", "pro")
+
+	require := t.Require()
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpClearAll: {types.UnsetValue: nil},
+	}, identify.Properties)
+
+	errors, warnings := identify.Validate()
+	require.Empty(errors)
+	require.Equal([]string{
+		"Operation $clearAll removes previous operations for properties name, visits",
+		"This Identify already contains a $clearAll operation, ignoring operation $set for property plan",
+	}, warnings)
+}
+
+func (t *IdentifySuite) TestValidate_Empty() {
+	identify := types.Identify{}
+
+	errors, warnings := identify.Validate()
+
+	require := t.Require()
+	require.Equal([]string{"Empty Properties"}, errors)
+	require.Empty(warnings)
+	require.False(identify.Validation().IsValid())
+}
+
+func (t *IdentifySuite) TestMerge() {
+	identify := types.Identify{}
+	identify.Set("name", "user-A").Add("visits", 1)
+
+	other := types.Identify{}
+	other.Set("name", "user-B").Set("plan", "pro").Append("tags", "a").Add("visits", "1")
+
+	identify.Merge(other)
+
+	require := t.Require()
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpSet:    {"name": "user-A", "plan": "pro"},
+		types.IdentityOpAdd:    {"visits": 1},
+		types.IdentityOpAppend: {"tags": "a"},
+	}, identify.Properties)
+
+	_, warnings := identify.Validate()
+	require.Equal([]string{
+		"Operation $add for property visits expects a number, got string, ignoring",
+		"Already used property name in previous operation, ignoring operation $set",
+	}, warnings)
+
+	clearAll := types.Identify{}
+	clearAll.ClearAll()
+
+	identify.Merge(clearAll)
+	require.Equal(map[types.IdentityOp]map[string]interface{}{
+		types.IdentityOpClearAll: {types.UnsetValue: nil},
+	}, identify.Properties)
+}
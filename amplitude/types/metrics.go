@@ -0,0 +1,9 @@
+package types
+
+// Metrics receives counters, gauges and histogram observations of the SDK pipeline.
+// Implementations must be safe for concurrent use. Labels may be nil.
+type Metrics interface {
+	AddCounter(name string, value float64, labels map[string]string)
+	SetGauge(name string, value float64, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
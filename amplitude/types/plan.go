@@ -1,8 +1,8 @@
 package types
 
 type Plan struct {
-	Branch    string `json:"branch,omitempty"`
-	Source    string `json:"source,omitempty"`
-	Version   string `json:"version,omitempty"`
-	VersionID string `json:"versionId,omitempty"`
+	Branch    string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	VersionID string `json:"versionId,omitempty" yaml:"versionId,omitempty"`
 }
@@ -1,5 +1,7 @@
 package types
 
+import "context"
+
 type (
 	PluginType int
 )
@@ -31,6 +33,20 @@ type DestinationPlugin interface {
 	Execute(event *Event)
 }
 
+// ContextDestinationPlugin is a DestinationPlugin that reports whether it accepted the event.
+// ExecuteContext must not block past the context deadline.
+type ContextDestinationPlugin interface {
+	DestinationPlugin
+	ExecuteContext(ctx context.Context, event *Event) error
+}
+
+// ResultObserverPlugin is a Plugin notified of the result of every event reported by the Amplitude destination,
+// e.g. to keep state about delivered events. ObserveResult is called while sending events, so it must not block.
+type ResultObserverPlugin interface {
+	Plugin
+	ObserveResult(result ExecuteResult)
+}
+
 type ExtendedDestinationPlugin interface {
 	DestinationPlugin
 	Flush()
@@ -39,7 +55,12 @@ type ExtendedDestinationPlugin interface {
 
 type ExecuteResult struct {
 	PluginName string
-	Event      *Event
-	Code       int
-	Message    string
+
+	// Event is nil for results not related to a single event, e.g. circuit breaker state changes.
+	Event   *Event
+	Code    int
+	Message string
+
+	// Endpoint is the API the event was sent to, it's empty if the event wasn't sent.
+	Endpoint Endpoint
 }
@@ -0,0 +1,335 @@
+package types
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxPropertiesDepth stops converting values nested deeper, e.g. structs pointing to themselves.
+const maxPropertiesDepth = 32
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	propertiesType    = reflect.TypeOf((*Properties)(nil))
+)
+
+// Properties builds event, user or group properties with typed setters, e.g.
+//
+//	NewProperties().SetString("genre", "rock").SetObject("album", NewProperties().SetInt("year", 1999)).Build()
+//
+// Values are copied when they are set, so later changes of a struct, slice or map don't change the properties.
+type Properties struct {
+	properties       map[string]interface{}
+	validateWarnings []string
+}
+
+func NewProperties() *Properties {
+	return &Properties{properties: make(map[string]interface{})}
+}
+
+// SetString sets a string property.
+func (p *Properties) SetString(property string, value string) *Properties {
+	return p.set(property, value)
+}
+
+// SetInt sets an integer property.
+func (p *Properties) SetInt(property string, value int64) *Properties {
+	return p.set(property, value)
+}
+
+// SetFloat sets a number property.
+func (p *Properties) SetFloat(property string, value float64) *Properties {
+	return p.set(property, value)
+}
+
+// SetBool sets a boolean property.
+func (p *Properties) SetBool(property string, value bool) *Properties {
+	return p.set(property, value)
+}
+
+// SetObject sets a nested object property.
+func (p *Properties) SetObject(property string, value *Properties) *Properties {
+	return p.set(property, value.Build())
+}
+
+// SetArray sets an array property, values are converted like values of Set.
+func (p *Properties) SetArray(property string, values ...interface{}) *Properties {
+	return p.Set(property, values)
+}
+
+// Set sets a property of any value that can be encoded as JSON.
+// Structs are converted to objects by SetStruct, slices and arrays to []interface{}
+// and maps to map[string]interface{}. Values implementing json.Marshaler or encoding.TextMarshaler,
+// e.g. time.Time, are kept as they are. Values that can't be encoded are ignored with a warning of Validate.
+func (p *Properties) Set(property string, value interface{}) *Properties {
+	converted, err := toPropertyValue(reflect.ValueOf(value), 0)
+	if err != nil {
+		p.validateWarnings = append(p.validateWarnings, fmt.Sprintf("Property %s is ignored: %s", property, err))
+
+		return p
+	}
+
+	return p.set(property, converted)
+}
+
+// SetStruct sets a property for every exported field of a struct or pointer to struct.
+// The name of a property is taken from the `amplitude:"name"` tag of the field, then the json tag, then the field name.
+// Fields tagged "-" are skipped, and fields tagged with omitempty are skipped if they are empty.
+// Fields of embedded structs are set as if they were fields of the outer struct.
+func (p *Properties) SetStruct(value interface{}) *Properties {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		p.validateWarnings = append(p.validateWarnings, fmt.Sprintf("SetStruct expects a struct, got %T, ignoring", value))
+
+		return p
+	}
+
+	fields, err := structProperties(v, 0)
+	if err != nil {
+		p.validateWarnings = append(p.validateWarnings, fmt.Sprintf("Struct %T is ignored: %s", value, err))
+
+		return p
+	}
+
+	for property, fieldValue := range fields {
+		p.set(property, fieldValue)
+	}
+
+	return p
+}
+
+// Build returns a copy of the properties, e.g. for Event.EventProperties.
+func (p *Properties) Build() map[string]interface{} {
+	if p == nil {
+		return nil
+	}
+
+	return cloneProperties(p.properties)
+}
+
+// Validate returns warnings about ignored properties.
+func (p *Properties) Validate() []string {
+	return p.validateWarnings
+}
+
+func (p *Properties) set(property string, value interface{}) *Properties {
+	if property == "" {
+		p.validateWarnings = append(p.validateWarnings, "Attempting to set a property with an empty name, ignoring")
+
+		return p
+	}
+
+	if p.properties == nil {
+		p.properties = make(map[string]interface{})
+	}
+
+	p.properties[property] = value
+
+	return p
+}
+
+// toPropertyValue converts a value to strings, numbers, booleans, []interface{} and map[string]interface{}.
+func toPropertyValue(v reflect.Value, depth int) (interface{}, error) {
+	if depth > maxPropertiesDepth {
+		return nil, fmt.Errorf("nested deeper than %d levels", maxPropertiesDepth)
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Type() == propertiesType {
+		return v.Interface().(*Properties).Build(), nil
+	}
+
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+
+		return cloneUnknown(v.Interface()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		return toPropertyValue(v.Elem(), depth)
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		values := make([]interface{}, v.Len())
+
+		for i := range values {
+			value, err := toPropertyValue(v.Index(i), depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+
+			values[i] = value
+		}
+
+		return values, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		values := make(map[string]interface{}, v.Len())
+
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := toPropertyValue(iter.Value(), depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			values[key] = value
+		}
+
+		return values, nil
+	case reflect.Struct:
+		return structProperties(v, depth)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// mapKey converts a map key to a string the way encoding/json does.
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+
+		return string(text), err
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+
+	return "", fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
+func structProperties(v reflect.Value, depth int) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, v.NumField())
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, omitEmpty, ok := propertyTag(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := v.Field(i)
+		if omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				embeddedProperties, err := structProperties(embedded, depth+1)
+				if err != nil {
+					return nil, err
+				}
+
+				for property, value := range embeddedProperties {
+					if _, ok := properties[property]; !ok {
+						properties[property] = value
+					}
+				}
+
+				continue
+			}
+
+			if field.PkgPath != "" {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		value, err := toPropertyValue(fieldValue, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+
+		properties[name] = value
+	}
+
+	return properties, nil
+}
+
+// propertyTag returns the name of the property of a struct field and whether it has omitempty,
+// it returns false if the field is skipped with "-".
+func propertyTag(field reflect.StructField) (string, bool, bool) {
+	tag, ok := field.Tag.Lookup("amplitude")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name, options, _ := cut(tag, ",")
+
+	return name, strings.Contains(","+options+",", ",omitempty,"), true
+}
+
+func cut(s string, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
@@ -0,0 +1,121 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestProperties(t *testing.T) {
+	suite.Run(t, new(PropertiesSuite))
+}
+
+type PropertiesSuite struct {
+	suite.Suite
+}
+
+func (t *PropertiesSuite) TestSetters() {
+	properties := types.NewProperties().
+		SetString("string", "value").
+		SetInt("int", 1).
+		SetFloat("float", 2.5).
+		SetBool("bool", true).
+		SetObject("object", types.NewProperties().SetString("nested", "value")).
+		SetArray("array", "a", 1, types.NewProperties().SetBool("nested", false)).
+		Set("map", map[int][]string{1: {"a"}})
+
+	require := t.Require()
+	require.Equal(map[string]interface{}{
+		"string": "value",
+		"int":    int64(1),
+		"float":  2.5,
+		"bool":   true,
+		"object": map[string]interface{}{"nested": "value"},
+		"array":  []interface{}{"a", int64(1), map[string]interface{}{"nested": false}},
+		"map":    map[string]interface{}{"1": []interface{}{"a"}},
+	}, properties.Build())
+	require.Empty(properties.Validate())
+}
+
+func (t *PropertiesSuite) TestSetStruct() {
+	type Base struct {
+		Source string `amplitude:"source"`
+		Genre  string `amplitude:"base_genre"`
+	}
+
+	type Artist struct {
+		Name string `json:"name"`
+	}
+
+	type Song struct {
+		Base
+		Genre    string            `amplitude:"genre"`
+		Duration float64           `amplitude:"duration,omitempty"`
+		Rating   *int              `amplitude:"rating,omitempty"`
+		Artists  []Artist          `amplitude:"artists"`
+		Tags     map[string]string `amplitude:"tags,omitempty"`
+		Released time.Time         `amplitude:"released"`
+		Internal string            `amplitude:"-"`
+		Plain    bool
+		private  string
+	}
+
+	released := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	song := &Song{
+		Base:     Base{Source: "radio", Genre: "pop"},
+		Genre:    "rock",
+		Artists:  []Artist{{Name: "artist-A"}},
+		Released: released,
+		Internal: "internal",
+		private:  "private",
+	}
+
+	properties := types.NewProperties().SetStruct(song)
+
+	require := t.Require()
+	require.Equal(map[string]interface{}{
+		"source":     "radio",
+		"base_genre": "pop",
+		"genre":      "rock",
+		"artists":    []interface{}{map[string]interface{}{"name": "artist-A"}},
+		"released":   released,
+		"Plain":      false,
+	}, properties.Build())
+
+	song.Artists[0].Name = "changed"
+	require.Equal([]interface{}{map[string]interface{}{"name": "artist-A"}}, properties.Build()["artists"])
+
+	built := properties.Build()
+	built["genre"] = "changed"
+	require.Equal("rock", properties.Build()["genre"])
+}
+
+func (t *PropertiesSuite) TestWarnings() {
+	type Node struct {
+		Next *Node
+	}
+
+	node := &Node{}
+	node.Next = node
+
+	properties := types.NewProperties().
+		SetString("", "value").
+		Set("channel", make(chan int)).
+		Set("node", node).
+		SetStruct("not a struct").
+		SetString("valid", "value")
+
+	require := t.Require()
+	require.Equal(map[string]interface{}{"valid": "value"}, properties.Build())
+	warnings := properties.Validate()
+	require.Len(warnings, 4)
+	require.Equal("Attempting to set a property with an empty name, ignoring", warnings[0])
+	require.Equal("Property channel is ignored: unsupported type chan int", warnings[1])
+	require.True(strings.HasPrefix(warnings[2], "Property node is ignored: Next: Next: "), warnings[2])
+	require.True(strings.HasSuffix(warnings[2], "nested deeper than 32 levels"), warnings[2])
+	require.Equal("SetStruct expects a struct, got string, ignoring", warnings[3])
+}
@@ -0,0 +1,21 @@
+package types
+
+// QueueFullPolicy selects what the Amplitude destination does with a new event when its queue is full.
+type QueueFullPolicy int
+
+const (
+	// QueueFullPolicyDropNewest drops the new event.
+	QueueFullPolicyDropNewest QueueFullPolicy = iota
+
+	// QueueFullPolicyDropOldest drops the oldest queued event to make room for the new one.
+	QueueFullPolicyDropOldest
+
+	// QueueFullPolicyBlock waits up to Config.QueueFullBlockTimeout for room in the queue,
+	// then drops the new event.
+	QueueFullPolicyBlock
+
+	// QueueFullPolicySpillToStorage pushes the new event directly to the event storage,
+	// so it may be sent before events still waiting in the queue.
+	// The new event is dropped if the storage already holds Config.MaxStorageCapacity events.
+	QueueFullPolicySpillToStorage
+)
@@ -1,5 +1,16 @@
 package types
 
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// RevenueTypeRefund and RevenueTypeChargeback are revenue types whose amounts are sent as negative numbers.
+	RevenueTypeRefund     = "refund"
+	RevenueTypeChargeback = "chargeback"
+)
+
 type Revenue struct {
 	Price       float64
 	Quantity    int
@@ -10,6 +21,9 @@ type Revenue struct {
 	ReceiptSig  string
 	Properties  map[string]interface{}
 	Revenue     float64
+
+	// OrderID links the revenue events of the items of an order, see RevenueOrder.
+	OrderID string
 }
 
 func (r Revenue) Validate() []string {
@@ -18,5 +32,103 @@ func (r Revenue) Validate() []string {
 		validateErrors = append(validateErrors, "Either Revenue or Price should be set")
 	}
 
+	if math.IsNaN(r.Revenue) || math.IsInf(r.Revenue, 0) || math.IsNaN(r.Price) || math.IsInf(r.Price, 0) {
+		validateErrors = append(validateErrors, "Revenue and Price should be finite numbers")
+	}
+
+	if r.Quantity < 0 {
+		validateErrors = append(validateErrors, "Quantity should not be negative")
+	}
+
 	return validateErrors
 }
+
+// IsRefund reports whether the revenue is a refund or chargeback.
+func (r Revenue) IsRefund() bool {
+	return r.RevenueType == RevenueTypeRefund || r.RevenueType == RevenueTypeChargeback
+}
+
+// SignedAmounts returns Price and Revenue, made negative for refunds and chargebacks.
+func (r Revenue) SignedAmounts() (float64, float64) {
+	if r.IsRefund() {
+		return -math.Abs(r.Price), -math.Abs(r.Revenue)
+	}
+
+	return r.Price, r.Revenue
+}
+
+// RevenueOrder is a purchase of several items, sent as a revenue event for every item with the OrderID.
+type RevenueOrder struct {
+	OrderID string
+	Items   []Revenue
+
+	// Currency, RevenueType, Receipt and ReceiptSig apply to items without their own,
+	// e.g. RevenueTypeRefund for a refund of the whole order.
+	Currency    string
+	RevenueType string
+	Receipt     string
+	ReceiptSig  string
+
+	// Properties are added to the event of every item, properties of the item take precedence.
+	Properties map[string]interface{}
+}
+
+func (o RevenueOrder) Validate() []string {
+	var validateErrors []string
+	if o.OrderID == "" {
+		validateErrors = append(validateErrors, "OrderID should be set")
+	}
+
+	if len(o.Items) == 0 {
+		validateErrors = append(validateErrors, "Items should not be empty")
+	}
+
+	for i, item := range o.Revenues() {
+		for _, validateError := range item.Validate() {
+			validateErrors = append(validateErrors, fmt.Sprintf("Item %d: %s", i, validateError))
+		}
+	}
+
+	return validateErrors
+}
+
+// Revenues returns the revenue of every item with the OrderID and the fields of the order applied.
+func (o RevenueOrder) Revenues() []Revenue {
+	revenues := make([]Revenue, len(o.Items))
+
+	for i, item := range o.Items {
+		item.OrderID = o.OrderID
+		item.Currency = firstNonEmpty(item.Currency, o.Currency)
+		item.RevenueType = firstNonEmpty(item.RevenueType, o.RevenueType)
+		item.Receipt = firstNonEmpty(item.Receipt, o.Receipt)
+		item.ReceiptSig = firstNonEmpty(item.ReceiptSig, o.ReceiptSig)
+
+		if len(o.Properties) > 0 {
+			properties := make(map[string]interface{}, len(o.Properties)+len(item.Properties))
+
+			for property, value := range o.Properties {
+				properties[property] = value
+			}
+
+			for property, value := range item.Properties {
+				properties[property] = value
+			}
+
+			item.Properties = properties
+		}
+
+		revenues[i] = item
+	}
+
+	return revenues
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
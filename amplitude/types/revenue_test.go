@@ -0,0 +1,78 @@
+package types_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/amplitude/analytics-go/amplitude/types"
+)
+
+func TestRevenue(t *testing.T) {
+	suite.Run(t, new(RevenueSuite))
+}
+
+type RevenueSuite struct {
+	suite.Suite
+}
+
+func (t *RevenueSuite) TestValidate() {
+	require := t.Require()
+	require.Empty(types.Revenue{Price: 1, Quantity: 2}.Validate())
+	require.Empty(types.Revenue{Revenue: -5, RevenueType: types.RevenueTypeRefund}.Validate())
+
+	require.Equal([]string{"Either Revenue or Price should be set"}, types.Revenue{}.Validate())
+	require.Equal([]string{"Revenue and Price should be finite numbers"}, types.Revenue{Price: math.NaN()}.Validate())
+	require.Equal([]string{"Quantity should not be negative"}, types.Revenue{Price: 1, Quantity: -1}.Validate())
+}
+
+func (t *RevenueSuite) TestSignedAmounts() {
+	require := t.Require()
+
+	price, revenue := types.Revenue{Price: 2, Revenue: 4}.SignedAmounts()
+	require.Equal([]float64{2, 4}, []float64{price, revenue})
+
+	price, revenue = types.Revenue{Price: 2, Revenue: -4, RevenueType: types.RevenueTypeRefund}.SignedAmounts()
+	require.Equal([]float64{-2, -4}, []float64{price, revenue})
+
+	price, revenue = types.Revenue{Price: 2, RevenueType: types.RevenueTypeChargeback}.SignedAmounts()
+	require.Equal(-2.0, price)
+	require.Zero(revenue)
+}
+
+func (t *RevenueSuite) TestOrderRevenues() {
+	order := types.RevenueOrder{
+		OrderID:    "order-1",
+		Currency:   "EUR",
+		Receipt:    "receipt-1",
+		Properties: map[string]interface{}{"channel": "web", "coupon": "SPRING"},
+		Items: []types.Revenue{
+			{ProductID: "p1", Price: 10, Quantity: 2},
+			{ProductID: "p2", Price: 5, Currency: "USD", Properties: map[string]interface{}{"coupon": "NONE"}},
+		},
+	}
+
+	require := t.Require()
+	require.Empty(order.Validate())
+	require.Equal([]types.Revenue{
+		{
+			ProductID: "p1", Price: 10, Quantity: 2, Currency: "EUR", Receipt: "receipt-1", OrderID: "order-1",
+			Properties: map[string]interface{}{"channel": "web", "coupon": "SPRING"},
+		},
+		{
+			ProductID: "p2", Price: 5, Currency: "USD", Receipt: "receipt-1", OrderID: "order-1",
+			Properties: map[string]interface{}{"channel": "web", "coupon": "NONE"},
+		},
+	}, order.Revenues())
+	require.Equal(map[string]interface{}{"coupon": "NONE"}, order.Items[1].Properties, "item properties are not changed")
+}
+
+func (t *RevenueSuite) TestOrderValidate() {
+	require := t.Require()
+	require.Equal([]string{"OrderID should be set", "Items should not be empty"}, types.RevenueOrder{}.Validate())
+	require.Equal([]string{"Item 1: Either Revenue or Price should be set"}, types.RevenueOrder{
+		OrderID: "order-1",
+		Items:   []types.Revenue{{Price: 1}, {ProductID: "p2"}},
+	}.Validate())
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"context"
+)
+
+// TracerProvider creates tracers for spans of the event lifecycle, see Config.TracerProvider.
+// It isn't OpenTelemetry, so the SDK doesn't depend on it. An OpenTelemetry TracerProvider is adapted
+// by NewTracerProvider of the github.com/amplitude/analytics-go/amplitude/tracing/otel module.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
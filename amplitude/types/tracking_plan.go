@@ -0,0 +1,38 @@
+package types
+
+// TrackingPlan lists the planned events and their properties, see the trackingplan package to load it from a file.
+// Plan identifies the version of the tracking plan.
+type TrackingPlan struct {
+	Plan   `yaml:",inline"`
+	Events []TrackingPlanEvent `json:"events" yaml:"events"`
+}
+
+type TrackingPlanEvent struct {
+	Name       string                 `json:"name" yaml:"name"`
+	Properties []TrackingPlanProperty `json:"properties,omitempty" yaml:"properties,omitempty"`
+
+	// AllowUnplannedProperties accepts event properties that are not listed in Properties.
+	AllowUnplannedProperties bool `json:"allowUnplannedProperties,omitempty" yaml:"allowUnplannedProperties,omitempty"`
+}
+
+type TrackingPlanProperty struct {
+	Name     string       `json:"name" yaml:"name"`
+	Type     PropertyType `json:"type,omitempty" yaml:"type,omitempty"`
+	Required bool         `json:"required,omitempty" yaml:"required,omitempty"`
+
+	// Enum lists the allowed values if it's not empty.
+	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// PropertyType is the JSON type of a planned property, an empty type accepts any value.
+type PropertyType string
+
+const (
+	PropertyTypeAny     PropertyType = "any"
+	PropertyTypeString  PropertyType = "string"
+	PropertyTypeNumber  PropertyType = "number"
+	PropertyTypeInteger PropertyType = "integer"
+	PropertyTypeBoolean PropertyType = "boolean"
+	PropertyTypeArray   PropertyType = "array"
+	PropertyTypeObject  PropertyType = "object"
+)
@@ -0,0 +1,15 @@
+package types
+
+// TrackingPlanMode selects what the tracking plan plugin does with an event violating the tracking plan.
+type TrackingPlanMode int
+
+const (
+	// TrackingPlanModeLog logs the violations and keeps the event as is.
+	TrackingPlanModeLog TrackingPlanMode = iota
+
+	// TrackingPlanModeAnnotate lists the violations in an event property.
+	TrackingPlanModeAnnotate
+
+	// TrackingPlanModeBlock drops the event and reports it through Config.ExecuteCallback.
+	TrackingPlanModeBlock
+)
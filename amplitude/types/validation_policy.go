@@ -0,0 +1,16 @@
+package types
+
+// ValidationPolicy selects what the validation plugin does with an invalid event.
+type ValidationPolicy int
+
+const (
+	// ValidationPolicyReject drops the invalid event and reports it through Config.ExecuteCallback.
+	ValidationPolicyReject ValidationPolicy = iota
+
+	// ValidationPolicyRepair fixes what can be fixed, e.g. drops properties that can't be encoded,
+	// and rejects the event if anything else is invalid.
+	ValidationPolicyRepair
+
+	// ValidationPolicyPassThrough logs the problems and keeps the event as is.
+	ValidationPolicyPassThrough
+)
@@ -0,0 +1,51 @@
+// Command ampligen generates Go functions that build the events of a tracking plan,
+// so event types and properties are checked by the compiler, e.g. with
+//
+//	//go:generate go run github.com/amplitude/analytics-go/cmd/ampligen -plan tracking-plan.yaml -package ampli -output ampli.go
+//
+// The tracking plan is a JSON or YAML file, see trackingplan.Parse.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/amplitude/analytics-go/amplitude/trackingplan"
+)
+
+func main() {
+	planPath := flag.String("plan", "tracking-plan.json", "path of the tracking plan")
+	packageName := flag.String("package", "ampli", "name of the generated package")
+	output := flag.String("output", "ampli.go", "path of the generated file, - for stdout")
+	flag.Parse()
+
+	if err := run(*planPath, *packageName, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "ampligen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(planPath string, packageName string, output string) error {
+	plan, err := trackingplan.Load(planPath)
+	if err != nil {
+		return err
+	}
+
+	code, err := trackingplan.Generate(plan, trackingplan.GeneratorOptions{
+		Package: packageName,
+		Source:  filepath.Base(planPath),
+	})
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		_, err = os.Stdout.Write(code)
+
+		return err
+	}
+
+	return os.WriteFile(output, code, 0o644)
+}
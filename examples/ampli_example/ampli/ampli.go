@@ -0,0 +1,85 @@
+// Code generated by ampligen from tracking-plan.yaml. DO NOT EDIT.
+
+package ampli
+
+import "github.com/amplitude/analytics-go/amplitude"
+
+// Plan is the version of the tracking plan the events are generated from.
+var Plan = amplitude.Plan{Branch: "main", Version: "1"}
+
+func newPlan() *amplitude.Plan {
+	plan := Plan
+
+	return &plan
+}
+
+// SongPlayedGenre is a value of the "genre" property of the "Song Played" event.
+type SongPlayedGenre string
+
+const (
+	SongPlayedGenreRock   SongPlayedGenre = "rock"
+	SongPlayedGenreJazz   SongPlayedGenre = "jazz"
+	SongPlayedGenreHipHop SongPlayedGenre = "hip hop"
+)
+
+// SongPlayedProps are the properties of the "Song Played" event, optional properties may be nil or empty.
+type SongPlayedProps struct {
+	SongID   string
+	Genre    SongPlayedGenre
+	Duration *float64
+	Artists  []interface{}
+}
+
+// SongPlayed builds the "Song Played" event for the user.
+func SongPlayed(userID string, props SongPlayedProps) amplitude.Event {
+	properties := make(map[string]interface{})
+
+	properties["song_id"] = props.SongID
+	if props.Genre != "" {
+		properties["genre"] = string(props.Genre)
+	}
+	if props.Duration != nil {
+		properties["duration"] = *props.Duration
+	}
+	if props.Artists != nil {
+		properties["artists"] = props.Artists
+	}
+
+	return amplitude.Event{
+		EventType:       "Song Played",
+		EventOptions:    amplitude.EventOptions{UserID: userID, Plan: newPlan()},
+		EventProperties: properties,
+	}
+}
+
+// PlaylistCreatedProps are the properties of the "Playlist Created" event, optional properties may be nil or empty.
+type PlaylistCreatedProps struct {
+	SongCount int
+
+	// Extra are unplanned properties, planned properties take precedence.
+	Extra map[string]interface{}
+}
+
+// PlaylistCreated builds the "Playlist Created" event for the user.
+func PlaylistCreated(userID string, props PlaylistCreatedProps) amplitude.Event {
+	properties := make(map[string]interface{})
+	for name, value := range props.Extra {
+		properties[name] = value
+	}
+
+	properties["song_count"] = props.SongCount
+
+	return amplitude.Event{
+		EventType:       "Playlist Created",
+		EventOptions:    amplitude.EventOptions{UserID: userID, Plan: newPlan()},
+		EventProperties: properties,
+	}
+}
+
+// AppOpened builds the "App Opened" event for the user.
+func AppOpened(userID string) amplitude.Event {
+	return amplitude.Event{
+		EventType:    "App Opened",
+		EventOptions: amplitude.EventOptions{UserID: userID, Plan: newPlan()},
+	}
+}
@@ -0,0 +1,30 @@
+// An example of tracking events with functions generated from a tracking plan.
+
+//go:generate go run github.com/amplitude/analytics-go/cmd/ampligen -plan tracking-plan.yaml -package ampli -output ampli/ampli.go
+
+package main
+
+import (
+	"github.com/amplitude/analytics-go/amplitude"
+	"github.com/amplitude/analytics-go/examples/ampli_example/ampli"
+)
+
+func main() {
+	config := amplitude.NewConfig("your-api-key")
+
+	client := amplitude.NewClient(config)
+
+	// Properties are checked by the compiler, optional properties are pointers or empty enums
+	duration := 215.5
+
+	client.Track(ampli.SongPlayed("user-id", ampli.SongPlayedProps{
+		SongID:   "song-id",
+		Genre:    ampli.SongPlayedGenreJazz,
+		Duration: &duration,
+	}))
+
+	client.Track(ampli.AppOpened("user-id"))
+
+	// Flushes queued events and shuts down the client
+	client.Shutdown()
+}
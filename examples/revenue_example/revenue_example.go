@@ -11,7 +11,7 @@ func main() {
 
 	config := amplitude.NewConfig("your-api-key")
 
-	client := amplitude.NewClient(config)
+	client := amplitude.NewExtendedClient(config)
 
 	// Revenue struct is passed into Revenue method
 	// to send as a revenue event
@@ -22,6 +22,27 @@ func main() {
 	}
 	client.Revenue(revenueObj, amplitude.EventOptions{DeviceID: "revenue-device-id", UserID: "revenue-user-id"})
 
+	// RevenueOrder sends a revenue event for every item, linked by the order ID
+	order := amplitude.RevenueOrder{
+		OrderID:  "order-1",
+		Currency: "USD",
+		Items: []amplitude.Revenue{
+			{ProductID: "com.company.productID", Price: 3.99, Quantity: 2},
+			{ProductID: "com.company.otherProductID", Price: 9.99, Quantity: 1},
+		},
+	}
+	client.RevenueOrder(order, amplitude.EventOptions{UserID: "revenue-user-id"})
+
+	// Refunds are sent with negative amounts
+	refund := amplitude.Revenue{
+		Price:       3.99,
+		Quantity:    1,
+		ProductID:   "com.company.productID",
+		RevenueType: amplitude.RevenueTypeRefund,
+		OrderID:     "order-1",
+	}
+	client.Revenue(refund, amplitude.EventOptions{UserID: "revenue-user-id"})
+
 	// Flush the event buffer
 	client.Flush()
 
@@ -29,6 +29,21 @@ func main() {
 		EventProperties: map[string]interface{}{"source": "notification"},
 	})
 
+	// Build properties with typed setters or from a struct with amplitude tags
+	type Song struct {
+		Title string `amplitude:"title"`
+		Genre string `amplitude:"genre,omitempty"`
+	}
+
+	client.Track(amplitude.Event{
+		EventType: "Song Played",
+		UserID:    "user-id",
+		EventProperties: amplitude.NewProperties().
+			SetStruct(Song{Title: "song-title"}).
+			SetFloat("duration", 215.5).
+			Build(),
+	})
+
 	// Flush the event buffer
 	client.Flush()
 